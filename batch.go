@@ -0,0 +1,229 @@
+package gosvelt
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// the batch builds are served under this path
+// (e.g. /_gosvelt/b1a2b3c4d/assets/p0-1a2b3c4d.js)
+const svelteBuildPath = "/_gosvelt"
+
+// a page of a batch build
+type svelteBatchPage struct {
+	file string
+	opts *SvelteOptions
+}
+
+// the options of a page making its vite config, the pages
+// are built in a batch by vite config
+type svelteBatchConfig struct {
+	packageManager string
+	tailwindcss    bool
+	ssr            bool
+}
+
+func batchConfig(opts *SvelteOptions) svelteBatchConfig {
+	return svelteBatchConfig{
+		packageManager: opts.packageManager,
+		tailwindcss:    opts.tailwindcss,
+		ssr:            opts.ssr,
+	}
+}
+
+// the entry name of the i-th page of a batch build
+// (its src folder, vite input and output folder)
+func svelteBatchEntry(i int) string {
+	return "p" + strconv.Itoa(i)
+}
+
+// compile pages in one multi-entry vite build, the chunks shared by
+// the pages (e.g. the svelte runtime) are bundled once, the build id
+// and the build folder are returned, the pages must have the same
+// vite config (see svelteBatchConfig)
+//
+// the build folder has the assets folder and the manifest of vite,
+// and a folder by page entry (e.g. p0) with its css bundle and its
+// server bundle if any
+func buildSvelteBatch(pages []svelteBatchPage, apiClient string) (string, string, error) {
+	config := batchConfig(pages[0].opts)
+
+	opts := &SvelteOptions{
+		packageManager: config.packageManager,
+		tailwindcss:    config.tailwindcss,
+		ssr:            config.ssr,
+		apiClient:      &apiClient,
+	}
+
+	inputs := make([]string, len(pages))
+
+	for i, page := range pages {
+		if batchConfig(page.opts) != config {
+			return "", "", fmt.Errorf("svelte: %s and %s can't be built in a same batch (different vite config)", pages[0].file, page.file)
+		}
+
+		inputs[i] = path.Join("src", svelteBatchEntry(i), "main.ts")
+	}
+
+	if err := initSvelteEnv(opts); err != nil {
+		return "", "", err
+	}
+
+	workspace, err := newSvelteWorkspace(opts, inputs)
+	if err != nil {
+		return "", "", err
+	}
+
+	for i, page := range pages {
+		if err := copySvelteFiles(filepath.Join(workspace, "src", svelteBatchEntry(i)), page.file, page.opts); err != nil {
+			os.RemoveAll(workspace)
+			return "", "", err
+		}
+	}
+
+	buildHash, err := calculateBuildHash(workspace)
+	if err != nil {
+		os.RemoveAll(workspace)
+		return "", "", err
+	}
+
+	buildId := fmt.Sprintf("b%s", buildHash[:8])
+	buildFolder := filepath.Join(svelteWorkdir, buildId, "bundle")
+
+	if err := singleBuild(buildId, func() error {
+		return buildSvelteWorkspace(workspace, buildFolder, opts, func(workspace, outputFolder string, opts *SvelteOptions) error {
+			return compileSvelteBatch(workspace, outputFolder, pages, opts)
+		})
+	}); err != nil {
+		// the workspace is kept to get the full error
+		return "", "", err
+	}
+
+	if err := os.RemoveAll(workspace); err != nil {
+		return "", "", err
+	}
+
+	return buildId, buildFolder, nil
+}
+
+func compileSvelteBatch(workspace, outputFolder string, pages []svelteBatchPage, opts *SvelteOptions) error {
+	if opts.apiClient != nil {
+		if err := checkSvelteEnv(workspace); err != nil {
+			return err
+		}
+	}
+
+	if err := execFromDir(workspace, opts.packageManager, "run", "build"); err != nil {
+		return errViteCompile(opts.packageManager, workspace)
+	}
+
+	dist := filepath.Join(workspace, "dist")
+
+	manifest, err := copyViteBuild(dist, outputFolder)
+	if err != nil {
+		return err
+	}
+
+	for i, page := range pages {
+		entry := svelteBatchEntry(i)
+
+		chunk, ok := manifest[path.Join("src", entry, "main.ts")]
+		if !ok {
+			return fmt.Errorf("svelte: no bundle of %s in the vite manifest, builder error", page.file)
+		}
+
+		// the css of the page and of its chunks, in one bundle
+		if err := writeViteCss(dist, manifest, chunk, filepath.Join(outputFolder, entry, "bundle.css")); err != nil {
+			return err
+		}
+
+		if page.opts.ssr {
+			if err := buildSvelteServer(workspace, path.Join("src", entry), filepath.Join(outputFolder, entry, "server.mjs")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// build the pages collected by WithBatchBuild and serve their bundles,
+// the pages with a different vite config (e.g. with tailwindcss or
+// ssr) are built in another batch
+func (gs *GoSvelt) buildBatch() error {
+	var configs []svelteBatchConfig
+	batches := make(map[svelteBatchConfig][]*sveltePage)
+
+	for _, page := range gs.batch {
+		config := batchConfig(page.opts)
+		if _, ok := batches[config]; !ok {
+			configs = append(configs, config)
+		}
+
+		batches[config] = append(batches[config], page)
+	}
+
+	// every route is registered, the api client is complete
+	apiClient := gs.TypeScript()
+
+	for _, config := range configs {
+		batch := batches[config]
+
+		gs.builder.run(func() error {
+			return gs.buildBatchPages(batch, apiClient)
+		})
+	}
+
+	return gs.builder.wait()
+}
+
+func (gs *GoSvelt) buildBatchPages(batch []*sveltePage, apiClient string) error {
+	pages := make([]svelteBatchPage, len(batch))
+	for i, page := range batch {
+		pages[i] = svelteBatchPage{file: page.file, opts: page.opts}
+	}
+
+	buildId, buildFolder, err := buildSvelteBatch(pages, apiClient)
+	if err != nil {
+		return fmt.Errorf("svelte: cannot build the pages (%w)", err)
+	}
+
+	manifest, err := readViteManifest(buildFolder)
+	if err != nil {
+		return err
+	}
+
+	// the chunks and the assets reference each other relatively
+	assetsUrl := path.Join(svelteBuildPath, buildId, "assets")
+
+	if err := gs.serveViteAssets(assetsUrl, filepath.Join(buildFolder, "assets")); err != nil {
+		return err
+	}
+
+	for i, page := range batch {
+		entry := svelteBatchEntry(i)
+		chunk := manifest[path.Join("src", entry, "main.ts")]
+
+		// next to the assets, for the relative urls of the css
+		cssBundleUrl := path.Join(assetsUrl, entry+".bundle.css")
+		gs.assets.set(cssBundleUrl, filepath.Join(buildFolder, entry, "bundle.css"))
+
+		var preloads []string
+		for _, file := range manifest.imports(chunk) {
+			preloads = append(preloads, path.Join(svelteBuildPath, buildId, file))
+		}
+
+		if err := gs.setPageBuild(page, buildId, filepath.Join(buildFolder, entry), Map{
+			"js":      path.Join(svelteBuildPath, buildId, chunk.File),
+			"css":     cssBundleUrl,
+			"preload": modulePreloads(preloads),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package gosvelt
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// bind the request into v: the body decoded according to its
+// Content-Type, then the route params (`param` tag), the query
+// (`query` tag) and the headers (`header` tag), so a body can't
+// override a route param, and finally validate it (see Validate),
+// failed rules are returned as a 422 http error
+//
+//	like this:
+//	type UpdateUser struct {
+//		ID   int    `param:"id"`
+//		Name string `json:"name" form:"name"`
+//	}
+//
+//	var req UpdateUser
+//	if err := c.Bind(&req); err != nil {
+//		return err
+//	}
+func (c *Context) Bind(v interface{}) error {
+	if len(c.Req().Body()) != 0 {
+		if err := c.BindBody(v); err != nil {
+			return err
+		}
+	}
+
+	if err := c.BindParams(v); err != nil {
+		return err
+	}
+
+	if err := c.BindQuery(v); err != nil {
+		return err
+	}
+
+	if err := c.BindHeaders(v); err != nil {
+		return err
+	}
+
+	return validateRequest(v)
+}
+
+// bind the request body into v according to its Content-Type
+func (c *Context) BindBody(v interface{}) error {
+	ctype := string(c.Req().Header.ContentType())
+	if mediaType, _, err := mime.ParseMediaType(ctype); err == nil {
+		ctype = mediaType
+	}
+
+	switch ctype {
+	case MAppJSON:
+		return c.BindJSON(v)
+
+	case MAppForm, "multipart/form-data":
+		return c.BindForm(v)
+
+	case MAppXML, MTextXML:
+		return c.BindXML(v)
+
+	case MAppProto, "application/x-protobuf":
+		return c.BindProto(v)
+
+	default:
+		return ErrUnsupportedMediaType.WithInternal(fmt.Errorf("bind: unsupported content type %q", ctype))
+	}
+}
+
+// bind a json body into v
+func (c *Context) BindJSON(v interface{}) error {
+	if err := json.Unmarshal(c.Req().Body(), v); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "invalid json body").WithInternal(err)
+	}
+
+	return nil
+}
+
+// bind a xml body into v
+func (c *Context) BindXML(v interface{}) error {
+	if err := xml.Unmarshal(c.Req().Body(), v); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "invalid xml body").WithInternal(err)
+	}
+
+	return nil
+}
+
+// bind a protobuf body into v, which must be a proto.Message
+func (c *Context) BindProto(v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrUnsupportedMediaType.WithInternal(fmt.Errorf("bind: %T is not a proto.Message", v))
+	}
+
+	if err := proto.Unmarshal(c.Req().Body(), msg); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "invalid protobuf body").WithInternal(err)
+	}
+
+	return nil
+}
+
+// bind the query args into the `query` tagged fields of v
+func (c *Context) BindQuery(v interface{}) error {
+	args := c.Args()
+
+	return bindValues(v, "query", func(key string) []string {
+		return bytesToStrings(args.PeekMulti(key))
+	})
+}
+
+// bind the route params into the `param` tagged fields of v
+func (c *Context) BindParams(v interface{}) error {
+	return bindValues(v, "param", func(key string) []string {
+		if value, ok := c.Param(key).(string); ok {
+			return []string{value}
+		}
+
+		return nil
+	})
+}
+
+// bind the request headers into the `header` tagged fields of v
+func (c *Context) BindHeaders(v interface{}) error {
+	header := &c.Req().Header
+
+	return bindValues(v, "header", func(key string) []string {
+		return bytesToStrings(header.PeekAll(key))
+	})
+}
+
+// bind an url encoded or multipart form into the `form` tagged fields of v
+func (c *Context) BindForm(v interface{}) error {
+	if strings.HasPrefix(string(c.Req().Header.ContentType()), "multipart/form-data") {
+		form, err := c.fasthttpCtx.MultipartForm()
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, "invalid multipart form").WithInternal(err)
+		}
+
+		return bindValues(v, "form", func(key string) []string {
+			return form.Value[key]
+		})
+	}
+
+	args := c.fasthttpCtx.PostArgs()
+
+	return bindValues(v, "form", func(key string) []string {
+		return bytesToStrings(args.PeekMulti(key))
+	})
+}
+
+func bytesToStrings(values [][]byte) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+
+	return strs
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// set the fields of the struct pointed by v tagged with tag,
+// fields without values are left untouched
+func bindValues(v interface{}, tag string, get func(key string) []string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInternalServerError.WithInternal(fmt.Errorf("bind: %T is not a non nil pointer", v))
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil // nothing to bind, e.g. a map for the json body
+	}
+
+	return bindStruct(rv, tag, get)
+}
+
+func bindStruct(rv reflect.Value, tag string, get func(key string) []string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		// the exported fields of an unexported embedded struct are
+		// bound, like encoding/json
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		key, hasTag := field.Tag.Lookup(tag)
+		key, _, _ = strings.Cut(key, ",")
+
+		if key == "-" || (hasTag && !field.IsExported()) {
+			continue
+		}
+
+		fv := rv.Field(i)
+
+		// embedded or nested structs without tag
+		if !hasTag {
+			if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+				if err := bindStruct(fv, tag, get); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if key == "" {
+			key = field.Name
+		}
+
+		values := get(key)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setField(fv, values); err != nil {
+			return NewHTTPError(
+				http.StatusBadRequest,
+				fmt.Sprintf("invalid %s %q", tag, key),
+			).WithInternal(err)
+		}
+	}
+
+	return nil
+}
+
+// set a field from its string values
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+
+		return setField(fv.Elem(), values)
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+
+		for i, value := range values {
+			if err := setField(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+
+		fv.Set(slice)
+
+		return nil
+	}
+
+	return setScalar(fv, values[0])
+}
+
+func setScalar(fv reflect.Value, value string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			fv.SetInt(int64(d))
+
+			return nil
+		}
+
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+
+	case reflect.Slice: // []byte
+		fv.SetBytes([]byte(value))
+
+	default:
+		return fmt.Errorf("bind: unsupported field type %s", fv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package gosvelt
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindAddress struct {
+	City string `json:"city" form:"city"`
+}
+
+type bindUser struct {
+	ID      int           `param:"id" json:"id"`
+	Page    *int          `query:"page"`
+	Tags    []string      `query:"tag"`
+	Timeout time.Duration `query:"timeout"`
+	Since   time.Time     `query:"since"`
+	Token   string        `header:"X-Token"`
+	Name    string        `json:"name" form:"name" xml:"name" validate:"max=8"`
+	bindAddress
+}
+
+// register a route binding a bindUser, the bound value and the
+// status of the request are returned
+func bindRequest(t *testing.T, method, uri, body string, headers ...string) (bindUser, int) {
+	t.Helper()
+
+	var got bindUser
+
+	gs := New()
+	gs.add(method, "/users/:id", func(c *Context) error {
+		if err := c.Bind(&got); err != nil {
+			return err
+		}
+
+		return c.Text(http.StatusOK, "ok")
+	}, nil)
+
+	res := serveRequest(gs, method, uri, body, headers...)
+
+	return got, res.StatusCode()
+}
+
+func TestBindRouteParamWinsOverBody(t *testing.T) {
+	got, code := bindRequest(t, MPost, "/users/7", `{"id":9,"name":"gopher"}`, "Content-Type", MAppJSON)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+
+	if got.ID != 7 {
+		t.Errorf("ID = %d, the body overrode the route param", got.ID)
+	}
+
+	if got.Name != "gopher" {
+		t.Errorf("Name = %q, the body was not bound", got.Name)
+	}
+}
+
+func TestBindQueryAndHeaders(t *testing.T) {
+	page := 2
+
+	// on every method, not only GET
+	for _, method := range []string{MGet, MPost, MDelete} {
+		got, code := bindRequest(t, method, "/users/7?page=2&tag=a&tag=b&timeout=1s&since=2024-01-02T03:04:05Z", "", "X-Token", "secret")
+		if code != http.StatusOK {
+			t.Fatalf("%s: status = %d", method, code)
+		}
+
+		want := bindUser{
+			ID:      7,
+			Page:    &page,
+			Tags:    []string{"a", "b"},
+			Timeout: time.Second,
+			Since:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Token:   "secret",
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: bound %+v, want %+v", method, got, want)
+		}
+	}
+}
+
+func TestBindBody(t *testing.T) {
+	for ctype, body := range map[string]string{
+		MAppJSON:                          `{"name":"gopher","city":"paris"}`,
+		MAppJSON + "; charset=utf-8":      `{"name":"gopher","city":"paris"}`,
+		MAppForm:                          "name=gopher&city=paris",
+		MAppXML:                           "<bindUser><name>gopher</name></bindUser>",
+		"multipart/form-data; boundary=b": "--b\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\ngopher\r\n--b\r\nContent-Disposition: form-data; name=\"city\"\r\n\r\nparis\r\n--b--\r\n",
+	} {
+		got, code := bindRequest(t, MPut, "/users/1", body, "Content-Type", ctype)
+		if code != http.StatusOK {
+			t.Errorf("%s: status = %d", ctype, code)
+			continue
+		}
+
+		if got.Name != "gopher" || (ctype != MAppXML && got.City != "paris") {
+			t.Errorf("%s: bound %+v", ctype, got)
+		}
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, tt := range []struct {
+		uri, ctype, body string
+		code             int
+	}{
+		{"/users/x", "", "", http.StatusBadRequest},
+		{"/users/1?timeout=soon", "", "", http.StatusBadRequest},
+		{"/users/1", MAppJSON, `{"name":`, http.StatusBadRequest},
+		{"/users/1", "text/csv", "a,b", http.StatusUnsupportedMediaType},
+		{"/users/1", MAppJSON, `{"name":"a very long name"}`, http.StatusUnprocessableEntity},
+	} {
+		if _, code := bindRequest(t, MPost, tt.uri, tt.body, "Content-Type", tt.ctype); code != tt.code {
+			t.Errorf("%s %q: status = %d, want %d", tt.uri, tt.body, code, tt.code)
+		}
+	}
+}
+
+func TestBindValues(t *testing.T) {
+	values := map[string][]string{"n": {"3"}, "ok": {"true"}, "ids": {"1", "2"}, "f": {"1.5"}, "raw": {"abc"}}
+	get := func(key string) []string { return values[key] }
+
+	var v struct {
+		N       uint8   `query:"n"`
+		OK      *bool   `query:"ok"`
+		IDs     []int64 `query:"ids"`
+		F       float32 `query:"f"`
+		Raw     []byte  `query:"raw"`
+		Missing string  `query:"missing"`
+		Skipped string  `query:"-"`
+		Untag   []string
+	}
+
+	v.Missing = "default"
+
+	if err := bindValues(&v, "query", get); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.N != 3 || v.OK == nil || !*v.OK || !reflect.DeepEqual(v.IDs, []int64{1, 2}) || v.F != 1.5 || string(v.Raw) != "abc" {
+		t.Errorf("bound %+v", v)
+	}
+
+	// fields without values are left untouched
+	if v.Missing != "default" || v.Skipped != "" || v.Untag != nil {
+		t.Errorf("bound %+v", v)
+	}
+
+	if err := bindValues(v, "query", get); err == nil {
+		t.Error("binding into a non pointer must fail")
+	}
+
+	var overflow struct {
+		N int8 `query:"n"`
+	}
+
+	values["n"] = []string{"300"}
+
+	if err := bindValues(&overflow, "query", get); err == nil {
+		t.Error("an overflowing value must fail")
+	}
+}
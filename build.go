@@ -0,0 +1,142 @@
+package gosvelt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// the builds share the svelte env while the installs (template,
+// node modules) need it alone, see also lockFile across processes
+var svelteEnvLocker sync.RWMutex
+
+// lock the svelte env, shared by the builds
+// or exclusive for the installs
+func lockSvelteEnv(shared bool) (unlock func(), err error) {
+	if err := os.MkdirAll(svelteEnv, 0755); err != nil {
+		return nil, err
+	}
+
+	if shared {
+		svelteEnvLocker.RLock()
+	} else {
+		svelteEnvLocker.Lock()
+	}
+
+	unlockFile, err := lockFile(pathFromSvelteEnv(svelteEnvLock), shared)
+	if err != nil {
+		if shared {
+			svelteEnvLocker.RUnlock()
+		} else {
+			svelteEnvLocker.Unlock()
+		}
+
+		return nil, fmt.Errorf("svelte: cannot lock %s (%s)", svelteEnv, err)
+	}
+
+	return func() {
+		unlockFile()
+
+		if shared {
+			svelteEnvLocker.RUnlock()
+		} else {
+			svelteEnvLocker.Unlock()
+		}
+	}, nil
+}
+
+// the running builds by build id
+var (
+	svelteBuildsLock sync.Mutex
+	svelteBuildCalls = make(map[string]*svelteBuildCall)
+)
+
+type svelteBuildCall struct {
+	done chan struct{}
+	err  error
+}
+
+// run build once for a build id, the concurrent
+// calls wait for it and get its error
+func singleBuild(buildId string, build func() error) error {
+	svelteBuildsLock.Lock()
+
+	if call, ok := svelteBuildCalls[buildId]; ok {
+		svelteBuildsLock.Unlock()
+		<-call.done
+
+		return call.err
+	}
+
+	call := &svelteBuildCall{done: make(chan struct{})}
+	svelteBuildCalls[buildId] = call
+	svelteBuildsLock.Unlock()
+
+	call.err = build()
+
+	svelteBuildsLock.Lock()
+	delete(svelteBuildCalls, buildId)
+	svelteBuildsLock.Unlock()
+
+	close(call.done)
+
+	return call.err
+}
+
+// build the svelte pages in parallel, at most
+// size at a time (see WithBuildWorkers)
+type svelteBuilder struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+	lock  sync.Mutex
+	errs  []error
+}
+
+func newSvelteBuilder(size int) *svelteBuilder {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+
+	return &svelteBuilder{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// run fn when a worker is free, its error
+// is returned by wait
+func (b *svelteBuilder) run(fn func() error) {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		if err := b.do(fn); err != nil {
+			b.lock.Lock()
+			b.errs = append(b.errs, err)
+			b.lock.Unlock()
+		}
+	}()
+}
+
+// run fn when a worker is free and wait for it
+func (b *svelteBuilder) do(fn func() error) error {
+	b.slots <- struct{}{}
+	defer func() { <-b.slots }()
+
+	return fn()
+}
+
+// wait for the builds, their errors are returned once
+func (b *svelteBuilder) wait() error {
+	b.wg.Wait()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	err := errors.Join(b.errs...)
+	b.errs = nil
+
+	return err
+}
@@ -0,0 +1,82 @@
+package gosvelt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RouteInfo describe the route matched by a request
+type RouteInfo struct {
+	Method  string // request method
+	Pattern string // route path as registered (e.g. /users/:id)
+}
+
+// build the context of a request, it carry the request id and the
+// route info and is canceled on shutdown, or as soon as the client
+// close its connection with WithCancelOnDisconnect
+func (gs *GoSvelt) requestContext(ctx *fasthttp.RequestCtx) (context.Context, context.CancelFunc) {
+	pattern, _ := ctx.UserValue(routeKey{}).(string)
+	requestID, _ := ctx.UserValue(requestIDKey{}).(string)
+
+	parent := context.WithValue(gs.ctx, routeKey{}, RouteInfo{
+		Method:  string(ctx.Method()),
+		Pattern: pattern,
+	})
+	parent = context.WithValue(parent, requestIDKey{}, requestID)
+
+	reqCtx, cancel := context.WithCancel(parent)
+
+	if !gs.config.cancelOnClose {
+		return reqCtx, cancel
+	}
+
+	stop := watchDisconnect(ctx.Conn(), cancel)
+
+	return reqCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// get the request id carried by a request context
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// get the route info carried by a request context
+func RouteFromContext(ctx context.Context) RouteInfo {
+	route, _ := ctx.Value(routeKey{}).(RouteInfo)
+	return route
+}
+
+// Timeout is a middleware that set a deadline on the request
+// context, a handler returning the deadline error is converted
+// to a 503 service unavailable
+//
+//	like this:
+//	app.Get("/report", reportHandler, gosvelt.Timeout(5*time.Second))
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			parent := c.Ctx
+
+			ctx, cancel := context.WithTimeout(parent, d)
+			defer cancel()
+
+			c.Ctx = ctx
+			defer func() { c.Ctx = parent }()
+
+			err := next(c)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+				return NewHTTPError(http.StatusServiceUnavailable).WithInternal(err)
+			}
+
+			return err
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -20,9 +22,15 @@ import (
 type Context struct {
 	gosvelt     *GoSvelt
 	fasthttpCtx *fasthttp.RequestCtx
-	Ctx         context.Context
-	store       Map
-	lock        sync.RWMutex
+	// request scoped context, it is canceled when the handler
+	// return, when the client close its connection (see
+	// WithCancelOnDisconnect), after the shutdown drain or when a deadline set by the Timeout
+	// middleware is reached
+	Ctx    context.Context
+	cancel context.CancelFunc
+	store  Map
+	svelte Map
+	lock   sync.RWMutex
 }
 
 func (gs *GoSvelt) newContext() any {
@@ -37,7 +45,7 @@ func (c *Context) update(ctx *fasthttp.RequestCtx) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.Ctx = context.Background()
+	c.Ctx, c.cancel = c.gosvelt.requestContext(ctx)
 	c.fasthttpCtx = ctx
 	c.store = make(Map)
 }
@@ -46,8 +54,15 @@ func (c *Context) reset() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.Ctx = context.Background()
+	c.cancel = nil
 	c.fasthttpCtx = nil
 	c.store = nil
+	c.svelte = nil
 }
 
 func (c *Context) Req() *fasthttp.Request {
@@ -70,6 +85,16 @@ func (c *Context) Args() *fasthttp.Args {
 	return c.fasthttpCtx.QueryArgs()
 }
 
+// the request id (see HeaderRequestID)
+func (c *Context) RequestID() string {
+	return RequestIDFromContext(c.Ctx)
+}
+
+// the matched route of the request
+func (c *Context) RouteInfo() RouteInfo {
+	return RouteFromContext(c.Ctx)
+}
+
 // CONTEXT STORE -->
 
 func (c *Context) CSet(key, value string) {
@@ -144,6 +169,111 @@ func (c *Context) CReset() {
 
 // CONTEXT RESPONSES -->
 
+// give props to the root component of the svelte page, v is
+// a map or a struct encoded as json, several calls are merged
+//
+//	like this:
+//	app.Svelte("/users/:id", "views/User.svelte", func(c *gosvelt.Context, svelte gosvelt.Map) error {
+//		if err := c.SvelteProps(gosvelt.Map{"user": user}); err != nil {
+//			return err
+//		}
+//
+//		return c.Html(200, "assets/index.html", svelte)
+//	})
+//
+// NOTE: the props are rendered by `&{props}` in the html
+// template, or before `</body>` if it's not in the template
+func (c *Context) SvelteProps(v interface{}) error {
+	if c.svelte == nil {
+		return fmt.Errorf("gosvelt: svelte props can only be given in a svelte handler")
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return fmt.Errorf("gosvelt: svelte props must be a map or a struct")
+	}
+
+	merged, _ := c.svelte["props"].(svelteProps)
+	if merged == nil {
+		merged = make(svelteProps)
+	}
+
+	for key, value := range props {
+		merged[key] = value
+	}
+
+	c.svelte["props"] = merged
+
+	return nil
+}
+
+// props of a svelte page, rendered as a json script read by main.ts
+type svelteProps map[string]json.RawMessage
+
+func (p svelteProps) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	// json escape <, > and & so the script can't be closed
+	data, err := json.Marshal(map[string]json.RawMessage(p))
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf(`<script id="%s" type="application/json">%s</script>`, sveltePropsId, data)
+}
+
+var (
+	svelteScriptTag  = regexp.MustCompile(`<script\b[^>]*&\{js\}[^>]*>`)
+	svelteModuleType = regexp.MustCompile(`\btype\s*=\s*['"]?module\b`)
+	htmlPlaceholder  = regexp.MustCompile(`&\{([^{}]*)\}`)
+)
+
+// replace the &{key} placeholders of t in one pass, so a value is never
+// replaced again (e.g. a rendered page containing &{css}), unknown
+// placeholders are kept
+func replacePlaceholders(t string, placeholders map[string]string) string {
+	return htmlPlaceholder.ReplaceAllStringFunc(t, func(placeholder string) string {
+		if value, ok := placeholders[placeholder[2:len(placeholder)-1]]; ok {
+			return value
+		}
+
+		return placeholder
+	})
+}
+
+// the js bundle is a module (its chunks and assets are imported
+// relatively), a classic script can't load it
+func checkSvelteScript(t string) error {
+	for _, tag := range svelteScriptTag.FindAllString(t, -1) {
+		if !svelteModuleType.MatchString(tag) {
+			return fmt.Errorf("svelte: the js bundle is a module, load it with <script type='module' src='&{js}'></script> (got %s)", tag)
+		}
+	}
+
+	return nil
+}
+
+// check the svelte script of a template file (see WithTemplate)
+func checkSvelteTemplate(file string) error {
+	t, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("svelte: cannot read the template %s (%s)", file, err)
+	}
+
+	if err := checkSvelteScript(string(t)); err != nil {
+		return fmt.Errorf("%w in %s", err, file)
+	}
+
+	return nil
+}
+
 func (c *Context) Html(code int, t string, args ...any) error {
 	// check if it's a file or an string
 	// and if it's a path read it
@@ -160,6 +290,10 @@ func (c *Context) Html(code int, t string, args ...any) error {
 		}
 
 		t = string(content)
+
+		if c.gosvelt.dev != nil {
+			c.gosvelt.dev.addTemplate(file.Name())
+		}
 	}
 
 	var output string
@@ -172,26 +306,47 @@ func (c *Context) Html(code int, t string, args ...any) error {
 			placeholders[fmt.Sprintf("%d", i+1)] = fmt.Sprint(arg)
 		}
 
-		// replace placeholders in the template with values
-		for placeholder, value := range placeholders {
-			t = strings.ReplaceAll(t, fmt.Sprintf("&{%s}", placeholder), value)
+		output = replacePlaceholders(t, placeholders)
+
+	case Map:
+		// the ssr values are rendered first to get the errors
+		for _, value := range args[0].(Map) {
+			if v, ok := value.(interface{ prerender() error }); ok {
+				if err := v.prerender(); err != nil {
+					return err
+				}
+			}
 		}
 
-		output = t
+		// svelte props are injected before </body> if the
+		// template don't place them
+		var inject string
+
+		if _, ok := args[0].(Map)["props"].(svelteProps); ok && !strings.Contains(t, "&{props}") {
+			inject += "&{props}"
+		}
+
+		// live reload client of the dev mode
+		if c.gosvelt.dev != nil && c.svelte != nil {
+			inject += c.gosvelt.dev.script()
+		}
+
+		if inject != "" {
+			if i := strings.LastIndex(t, "</body>"); i != -1 {
+				t = t[:i] + inject + t[i:]
+
+			} else {
+				t += inject
+			}
+		}
 
-	case Map:
 		// create a map of placeholders to values
 		placeholders := make(map[string]string)
 		for key, value := range args[0].(Map) {
-			placeholders[fmt.Sprintf("&{%s}", key)] = fmt.Sprint(value)
-		}
-
-		// replace placeholders in the template with values
-		for placeholder, value := range placeholders {
-			t = strings.ReplaceAll(t, placeholder, value)
+			placeholders[key] = fmt.Sprint(value)
 		}
 
-		output = t
+		output = replacePlaceholders(t, placeholders)
 
 	default:
 		return fmt.Errorf("args must be...string or gosvelt.Map")
@@ -259,14 +414,26 @@ func (c *Context) GetForm(key string) []byte {
 
 // return ws connection
 // NOTE: this need websocket.FastHTTPHandler handler
-// and all ws code will be in the arg handler
+// and all ws code will be in the arg handler,
+// a panic in the handler is logged and close the connection
 func (c *Context) Ws(handler websocket.FastHTTPHandler) error {
 	upgrader := websocket.FastHTTPUpgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 	}
 
-	err := upgrader.Upgrade(c.fasthttpCtx, handler)
+	gs := c.gosvelt
+
+	err := upgrader.Upgrade(c.fasthttpCtx, func(conn *websocket.Conn) {
+		defer conn.Close()
+
+		if !gs.trackConn(conn) {
+			return // shutting down
+		}
+		defer gs.untrackConn(conn)
+
+		safeRun(gs.errorLogger(), "ws", func() { handler(conn) })
+	})
 	if err != nil {
 		return err
 	}
@@ -274,7 +441,14 @@ func (c *Context) Ws(handler websocket.FastHTTPHandler) error {
 	return nil
 }
 
+// stream datach to the client until closech is closed,
+// a panic in fn is logged and close the stream
 func (c *Context) Sse(datach chan interface{}, closech chan struct{}, fn func()) error {
+	// closed if fn panics
+	done := make(chan struct{})
+	// closed on server shutdown
+	shutdown := c.gosvelt.done
+
 	// cors headers
 	//c.SetHeader("Access-Control-Allow-Origin", "*")
 	//c.SetHeader("Access-Control-Allow-Headers", "Content-Type")
@@ -300,6 +474,14 @@ func (c *Context) Sse(datach chan interface{}, closech chan struct{}, fn func())
 				//Loop:
 				for {
 					select {
+					case <-done:
+						return
+
+					case <-shutdown:
+						// don't block fn on a stream nobody read
+						go drainSse(datach, closech)
+						return
+
 					case <-closech:
 						close(datach)
 
@@ -328,11 +510,27 @@ func (c *Context) Sse(datach chan interface{}, closech chan struct{}, fn func())
 	)
 
 	// start user func
-	go fn()
+	go func() {
+		if safeRun(c.gosvelt.errorLogger(), "sse", fn) {
+			close(done)
+		}
+	}()
 
 	return nil
 }
 
+// consume datas sent to a closed stream until fn close closech
+func drainSse(datach chan interface{}, closech chan struct{}) {
+	for {
+		select {
+		case <-closech:
+			return
+
+		case <-datach:
+		}
+	}
+}
+
 // return json datas to client
 func (c *Context) Json(code int, j interface{}) error {
 	c.SetCType(MAppJsonUTF8)
@@ -363,6 +561,44 @@ func (c *Context) Proto(code int, p proto.Message) error {
 	return nil
 }
 
+// return xml datas to client
+func (c *Context) Xml(code int, x interface{}) error {
+	c.SetCType(MAppXmlUTF8)
+
+	xmlData, err := xml.Marshal(x)
+	if err != nil {
+		return err
+	}
+
+	c.SetStatusCode(code)
+	c.Write([]byte(xml.Header))
+	c.Write(xmlData)
+
+	return nil
+}
+
+// return datas to client as json, xml or protobuf (if v is a
+// proto.Message) depending on the Accept header, json is the default
+func (c *Context) Negotiate(code int, v interface{}) error {
+	offers := []string{MAppJSON, MAppXML, MTextXML}
+
+	msg, isProto := v.(proto.Message)
+	if isProto {
+		offers = append(offers, MAppProto)
+	}
+
+	switch negotiateType(string(c.Req().Header.Peek("Accept")), offers...) {
+	case MAppXML, MTextXML:
+		return c.Xml(code, v)
+
+	case MAppProto:
+		return c.Proto(code, msg)
+
+	default:
+		return c.Json(code, v)
+	}
+}
+
 // return text datas to client
 func (c *Context) Text(code int, t string) error {
 	c.SetCType(MTextPlainUTF8)
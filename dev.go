@@ -0,0 +1,374 @@
+package gosvelt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	devPath         = "/_gosvelt/dev" // live reload event stream
+	devPollInterval = 300 * time.Millisecond
+	devPingInterval = 15 * time.Second
+)
+
+// the live reload client injected in the svelte pages, the page is
+// reloaded after a rebuild or a server restart and the stylesheet is
+// swapped when only the css changed
+const devClientScript = `<script>(() => {
+	const events = new EventSource(%q);
+	let server;
+	events.addEventListener('hello', (e) => {
+		if (server && server !== e.data) location.reload();
+		server = e.data;
+	});
+	events.addEventListener('reload', () => location.reload());
+	events.addEventListener('css', (e) => {
+		const { from, to } = JSON.parse(e.data);
+		for (const link of document.querySelectorAll('link[rel="stylesheet"]')) {
+			if (new URL(link.href).pathname === from) link.href = to;
+		}
+	});
+	events.addEventListener('builderror', (e) => console.error('[gosvelt] ' + e.data));
+})();</script>`
+
+// rebuild the svelte pages when their files change and push
+// the changes to the opened pages (see WithDev)
+type devServer struct {
+	gs        *GoSvelt
+	id        string // server instance, a new one reload the pages
+	lock      sync.Mutex
+	pages     []*sveltePage
+	templates map[string]struct{}
+	clients   map[chan devEvent]struct{}
+}
+
+type devEvent struct {
+	name string
+	data string
+}
+
+func (gs *GoSvelt) newDevServer() *devServer {
+	d := &devServer{
+		gs:        gs,
+		id:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		templates: make(map[string]struct{}),
+		clients:   make(map[chan devEvent]struct{}),
+	}
+
+	// not a documented route, and without middlewares
+	gs.handle(MGet, devPath, d.serve)
+
+	gs.OnStart(func() error {
+		go d.watch()
+		return nil
+	})
+
+	return d
+}
+
+func (d *devServer) addPage(page *sveltePage) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.pages = append(d.pages, page)
+}
+
+// watch a html template given to Context.Html
+func (d *devServer) addTemplate(file string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.templates[filepath.Clean(file)] = struct{}{}
+}
+
+// the files of a page, its root folder or its
+// svelte file and its layouts
+func (d *devServer) pageRoots(page *sveltePage) []string {
+	if page.opts.rootFolder != nil {
+		return []string{filepath.Clean(*page.opts.rootFolder)}
+	}
+
+	roots := []string{filepath.Clean(page.file)}
+	for _, layout := range page.opts.layouts {
+		roots = append(roots, filepath.Clean(layout))
+	}
+
+	return roots
+}
+
+// poll the watched files until the shutdown
+func (d *devServer) watch() {
+	ticker := time.NewTicker(devPollInterval)
+	defer ticker.Stop()
+
+	previous := d.snapshot()
+
+	for {
+		select {
+		case <-d.gs.done:
+			return
+
+		case <-ticker.C:
+		}
+
+		current := d.snapshot()
+		changed := make(map[string]struct{})
+
+		for root, stamps := range current {
+			// a new root (e.g. a template read for the
+			// first time) is not a change
+			before, ok := previous[root]
+			if !ok {
+				continue
+			}
+
+			for file, stamp := range stamps {
+				if before[file] != stamp {
+					changed[file] = struct{}{}
+				}
+			}
+			for file := range before {
+				if _, ok := stamps[file]; !ok {
+					changed[file] = struct{}{}
+				}
+			}
+		}
+
+		previous = current
+
+		if len(changed) > 0 {
+			d.apply(changed)
+		}
+	}
+}
+
+// the modification stamps of the watched files by root
+func (d *devServer) snapshot() map[string]map[string]string {
+	d.lock.Lock()
+	roots := make([]string, 0, len(d.pages)+len(d.templates))
+	for _, page := range d.pages {
+		roots = append(roots, d.pageRoots(page)...)
+	}
+	for file := range d.templates {
+		roots = append(roots, file)
+	}
+	d.lock.Unlock()
+
+	snapshot := make(map[string]map[string]string)
+
+	for _, root := range roots {
+		stamps := make(map[string]string)
+
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if entry.IsDir() {
+				if name := entry.Name(); path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if info, err := entry.Info(); err == nil {
+				stamps[path] = info.ModTime().String() + "/" + strconv.FormatInt(info.Size(), 10)
+			}
+
+			return nil
+		})
+
+		snapshot[root] = stamps
+	}
+
+	return snapshot
+}
+
+// rebuild the pages having changed files, in parallel
+func (d *devServer) apply(changed map[string]struct{}) {
+	d.lock.Lock()
+	pages := append([]*sveltePage(nil), d.pages...)
+
+	// the templates are read on each request
+	reload := false
+	for file := range changed {
+		if _, ok := d.templates[file]; ok {
+			reload = true
+			break
+		}
+	}
+	d.lock.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		eventMu sync.Mutex
+	)
+
+	apiClient := d.gs.TypeScript()
+
+	for _, page := range pages {
+		affected := false
+		for _, root := range d.pageRoots(page) {
+			for file := range changed {
+				if file == root || strings.HasPrefix(file, root+string(filepath.Separator)) {
+					affected = true
+				}
+			}
+		}
+
+		if !affected {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(page *sveltePage) {
+			defer wg.Done()
+
+			var event devEvent
+
+			err := d.gs.builder.do(func() (err error) {
+				event, err = d.rebuild(page, apiClient)
+				return err
+			})
+			if err != nil {
+				fmt.Printf("[DEV] cannot rebuild %s: %s\n", page.path, err)
+				d.broadcast(devEvent{name: "builderror", data: err.Error()})
+
+				return
+			}
+
+			switch event.name {
+			case "reload":
+				eventMu.Lock()
+				reload = true
+				eventMu.Unlock()
+			case "css":
+				d.broadcast(event)
+			}
+		}(page)
+	}
+
+	wg.Wait()
+
+	if reload {
+		d.broadcast(devEvent{name: "reload"})
+	}
+}
+
+// rebuild a page, a css event is returned if only its css changed
+func (d *devServer) rebuild(page *sveltePage, apiClient string) (devEvent, error) {
+	page.lock.RLock()
+	oldId, oldFolder, oldCss := page.buildId, page.folder, page.svelte["css"]
+	page.lock.RUnlock()
+
+	start := time.Now()
+
+	if err := d.gs.buildPage(page, apiClient); err != nil {
+		return devEvent{}, err
+	}
+
+	page.lock.RLock()
+	newId, newFolder, newCss := page.buildId, page.folder, page.svelte["css"]
+	page.lock.RUnlock()
+
+	if newId == oldId {
+		return devEvent{}, nil // same sources
+	}
+
+	fmt.Printf("[DEV] %s rebuilt in %s\n", page.path, time.Since(start).Round(time.Millisecond))
+
+	if !page.opts.ssr && sameFile(filepath.Join(oldFolder, "bundle.js"), filepath.Join(newFolder, "bundle.js")) {
+		data, _ := json.Marshal(map[string]interface{}{"from": oldCss, "to": newCss})
+		return devEvent{name: "css", data: string(data)}, nil
+	}
+
+	return devEvent{name: "reload"}, nil
+}
+
+func sameFile(a, b string) bool {
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false
+	}
+
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(dataA, dataB)
+}
+
+func (d *devServer) broadcast(event devEvent) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for client := range d.clients {
+		select {
+		case client <- event:
+		default: // a slow client miss the event
+		}
+	}
+}
+
+// stream the events to a page
+func (d *devServer) serve(ctx *fasthttp.RequestCtx) {
+	events := make(chan devEvent, 8)
+
+	d.lock.Lock()
+	d.clients[events] = struct{}{}
+	d.lock.Unlock()
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+	ctx.SetStatusCode(http.StatusOK)
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			d.lock.Lock()
+			delete(d.clients, events)
+			d.lock.Unlock()
+		}()
+
+		ping := time.NewTicker(devPingInterval)
+		defer ping.Stop()
+
+		fmt.Fprintf(w, "event: hello\ndata: %s\n\n", d.id)
+
+		for {
+			if err := w.Flush(); err != nil {
+				return // the page is closed
+			}
+
+			select {
+			case <-d.gs.done:
+				return
+
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+
+			case event := <-events:
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, strings.ReplaceAll(event.data, "\n", "\ndata: "))
+			}
+		}
+	})
+}
+
+// the live reload client
+func (d *devServer) script() string {
+	return fmt.Sprintf(devClientScript, devPath)
+}
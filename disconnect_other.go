@@ -0,0 +1,13 @@
+//go:build !unix
+
+package gosvelt
+
+import (
+	"context"
+	"net"
+)
+
+// disconnections can't be watched on this platform
+func watchDisconnect(conn net.Conn, cancel context.CancelFunc) (stop func()) {
+	return func() {}
+}
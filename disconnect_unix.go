@@ -0,0 +1,57 @@
+//go:build unix
+
+package gosvelt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// watch conn without consuming it and call cancel if the
+// client close it, stop must be called before the connection
+// is read again
+func watchDisconnect(conn net.Conn, cancel context.CancelFunc) (stop func()) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {}
+	}
+
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, 1)
+
+		// wait until the connection is readable then peek it,
+		// reading nothing means the client closed the connection
+		raw.Read(func(fd uintptr) bool {
+			n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+			if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EWOULDBLOCK) {
+				return false
+			}
+
+			if n == 0 || err != nil {
+				cancel()
+			}
+
+			return true
+		})
+	}()
+
+	return func() {
+		// unblock the watcher then restore the
+		// deadline, fasthttp set its own if needed
+		conn.SetReadDeadline(time.Unix(1, 0))
+		<-done
+		conn.SetReadDeadline(time.Time{})
+	}
+}
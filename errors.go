@@ -0,0 +1,85 @@
+package gosvelt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HTTPError is an error carrying the http status code and the
+// message that will be sent to the client, the internal cause
+// is only logged
+type HTTPError struct {
+	Code     int         `json:"status"`            // http status code
+	Message  string      `json:"error"`             // public message
+	Internal error       `json:"-"`                 // internal cause, never sent
+	Details  interface{} `json:"details,omitempty"` // optional public details
+}
+
+var (
+	ErrBadRequest           = NewHTTPError(http.StatusBadRequest)
+	ErrUnauthorized         = NewHTTPError(http.StatusUnauthorized)
+	ErrForbidden            = NewHTTPError(http.StatusForbidden)
+	ErrNotFound             = NewHTTPError(http.StatusNotFound)
+	ErrMethodNotAllowed     = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrUnsupportedMediaType = NewHTTPError(http.StatusUnsupportedMediaType)
+	ErrUnprocessableEntity  = NewHTTPError(http.StatusUnprocessableEntity)
+	ErrInternalServerError  = NewHTTPError(http.StatusInternalServerError)
+)
+
+// create a new http error, the message defaults
+// to the status text of the code
+//
+//	like this:
+//	return gosvelt.NewHTTPError(404, "user not found")
+func NewHTTPError(code int, message ...string) *HTTPError {
+	he := &HTTPError{
+		Code:    code,
+		Message: http.StatusText(code),
+	}
+
+	if len(message) > 0 {
+		he.Message = message[0]
+	}
+
+	return he
+}
+
+func (he *HTTPError) Error() string {
+	if he.Internal != nil {
+		return fmt.Sprintf("%d %s: %v", he.Code, he.Message, he.Internal)
+	}
+
+	return fmt.Sprintf("%d %s", he.Code, he.Message)
+}
+
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
+// return a copy of the error with an internal cause
+func (he *HTTPError) WithInternal(err error) *HTTPError {
+	e := *he
+	e.Internal = err
+
+	return &e
+}
+
+// return a copy of the error with public details
+func (he *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e := *he
+	e.Details = details
+
+	return &e
+}
+
+// convert any error to an http error, errors that are not
+// http errors become internal server errors
+func toHTTPError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+
+	return ErrInternalServerError.WithInternal(err)
+}
@@ -0,0 +1,42 @@
+package gosvelt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnroutedErrors(t *testing.T) {
+	var got []error
+
+	gs := New(WithErrorHandler(func(c *Context, err error) {
+		got = append(got, err)
+		c.Text(toHTTPError(err).Code, "handled")
+	}))
+
+	gs.Get("/users", String("users"))
+
+	for _, tt := range []struct {
+		method, uri string
+		err         *HTTPError
+	}{
+		{MGet, "/missing", ErrNotFound},
+		{MDelete, "/users", ErrMethodNotAllowed},
+	} {
+		got = nil
+
+		res := serveRequest(gs, tt.method, tt.uri, "")
+
+		if len(got) != 1 || !errors.Is(got[0], tt.err) {
+			t.Errorf("%s %s: errors = %v, want %v", tt.method, tt.uri, got, tt.err)
+		}
+
+		if res.StatusCode() != tt.err.Code || string(res.Body()) != "handled" {
+			t.Errorf("%s %s: %d %q", tt.method, tt.uri, res.StatusCode(), res.Body())
+		}
+	}
+
+	res := serveRequest(gs, MDelete, "/users", "")
+	if allow := string(res.Header.Peek("Allow")); allow != "GET, OPTIONS" {
+		t.Errorf("Allow = %q", allow)
+	}
+}
@@ -1,30 +1,32 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	gs "github.com/4lxprime/GoSvelt"
-	"github.com/valyala/fasthttp"
 )
 
-func ErrorHandler(c *fasthttp.RequestCtx, err error) {
-	statusCode := 500
+// only the public message of the error is sent,
+// the internal cause is logged
+func ErrorHandler(c *gs.Context, err error) {
+	he := gs.ErrInternalServerError
 
-	respBytes, err := json.Marshal(struct {
-		Status int    `json:"status"`
-		Error  string `json:"error"`
+	errors.As(err, &he)
+
+	log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+
+	c.Json(he.Code, struct {
+		Status  int         `json:"status"`
+		Error   string      `json:"error"`
+		Details interface{} `json:"details,omitempty"`
 	}{
-		Status: statusCode,
-		Error:  err.Error(),
+		Status:  he.Code,
+		Error:   he.Message,
+		Details: he.Details,
 	})
-	if err != nil {
-		return
-	}
-
-	c.SetBody(respBytes)
-	c.SetStatusCode(statusCode)
 }
 
 func main() {
@@ -80,6 +82,7 @@ func main() {
 			return c.Html(200, "assets/index.html", svelte)
 		},
 		gs.WithPackageManager("pnpm"),
+		gs.WithTemplate("assets/index.html"),
 	)
 
 	app.Svelte("/", "App.svelte",
@@ -89,7 +92,10 @@ func main() {
 		gs.WithPackageManager("pnpm"),
 		gs.WithTailwindcss,
 		gs.WithRoot("views"),
+		gs.WithTemplate("assets/index.html"),
 	)
 
-	app.Start(":8080")
+	if err := app.Start(":8080"); err != nil {
+		panic(err)
+	}
 }
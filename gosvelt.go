@@ -1,18 +1,20 @@
 package gosvelt
 
 import (
-	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
-	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/buaazp/fasthttprouter"
 	"github.com/dgrr/http2"
+	"github.com/fasthttp/websocket"
 	"github.com/valyala/fasthttp"
 )
 
@@ -61,10 +63,19 @@ const (
 
 type Options struct {
 	log            bool
+	logHandler     slog.Handler
 	http2          bool
 	errorHandler   ErrorHandlerFunc
+	recover        *RecoverConfig
+	server         ServerConfig
+	serverFns      []func(*fasthttp.Server)
 	tailwindcssCfg *string
 	postcssCfg     *string
+	ssrWorkers     int
+	buildWorkers   int
+	batch          bool
+	dev            bool
+	cancelOnClose  bool
 }
 type Option func(*Options)
 
@@ -74,15 +85,42 @@ type GoSvelt struct {
 	router            *fasthttprouter.Router
 	pool              sync.Pool
 	storePool         sync.Pool
-	middlewares       map[string]MiddlewareFunc
-	svelteMiddlewares map[string]SvelteMiddlewareFunc
+	middlewares       []middleware
+	svelteMiddlewares []svelteMiddleware
+	routes            []*Route
 	errHandler        ErrorHandlerFunc
+	logger            *slog.Logger // nil if the access log is disabled
+	onStart           []func() error
+	onShutdown        []func(ctx context.Context) error
+	prepareOnce       sync.Once
+	prepareErr        error
+	done              chan struct{}   // closed on shutdown
+	ctx               context.Context // parent of every request context
+	cancel            context.CancelFunc
+	shutdownOnce      sync.Once
+	conns             map[*websocket.Conn]struct{} // open ws connections
+	connsLock         sync.Mutex
+	ssr               *ssrPool // nil until a ssr page is registered
+	staticPages       []*staticPage
+	assets            assetTable // svelte bundles
+	builder           *svelteBuilder
+	batch             []*sveltePage // built at the start (see WithBatchBuild)
+	dev               *devServer    // nil if not in dev mode
 }
 
 var (
+	// log every served request (colored text by default)
 	WithLog = func(o *Options) {
 		o.log = true
 	}
+	// log every served request with a custom slog handler
+	// (e.g. slog.NewJSONHandler(os.Stdout, nil) in production)
+	WithLogHandler = func(handler slog.Handler) Option {
+		return func(o *Options) {
+			o.log = true
+			o.logHandler = handler
+		}
+	}
 	WithHttp2 = func(o *Options) {
 		o.http2 = true
 	}
@@ -91,6 +129,28 @@ var (
 			o.errorHandler = errorHandler
 		}
 	}
+	// recover every handler panic (see Recover)
+	WithRecover = func(config ...RecoverConfig) Option {
+		return func(o *Options) {
+			o.recover = new(RecoverConfig)
+			if len(config) > 0 {
+				*o.recover = config[0]
+			}
+		}
+	}
+	// tune the fasthttp server (timeouts, limits, ...)
+	WithServerConfig = func(config ServerConfig) Option {
+		return func(o *Options) {
+			o.server = config
+		}
+	}
+	// direct access to the fasthttp server, applied after
+	// WithServerConfig (the handler is always overridden)
+	WithServer = func(fn func(*fasthttp.Server)) Option {
+		return func(o *Options) {
+			o.serverFns = append(o.serverFns, fn)
+		}
+	}
 	WithTailwind = func(tailwindConfig string) Option {
 		return func(o *Options) {
 			o.tailwindcssCfg = &tailwindConfig
@@ -101,13 +161,48 @@ var (
 			o.postcssCfg = &postcssConfig
 		}
 	}
+	// dev mode, the svelte pages are rebuilt when their files (or
+	// the html templates) change and the opened pages are reloaded
+	WithDev = func(o *Options) {
+		o.dev = true
+	}
+	// number of node workers rendering the ssr pages
+	// (default to the number of cpus, see WithSSR)
+	WithSSRWorkers = func(workers int) Option {
+		return func(o *Options) {
+			o.ssrWorkers = workers
+		}
+	}
+	// number of svelte pages built in parallel
+	// (default to the number of cpus)
+	WithBuildWorkers = func(workers int) Option {
+		return func(o *Options) {
+			o.buildWorkers = workers
+		}
+	}
+	// build the svelte pages in one vite build (by vite config) at the start, the
+	// chunks shared by the pages (e.g. the svelte runtime) are then
+	// downloaded once (ignored in dev mode)
+	WithBatchBuild = func(o *Options) {
+		o.batch = true
+	}
+	// cancel the request context as soon as the client close its
+	// connection (plain tcp and unix connections only), it cost a
+	// goroutine by request so it's only worth it for long handlers
+	WithCancelOnDisconnect = func(o *Options) {
+		o.cancelOnClose = true
+	}
 )
 
 func initOptions(options []Option) *Options {
 	opts := &Options{
 		log:            false,
+		logHandler:     nil,
 		http2:          false,
 		errorHandler:   defaultErrorHandler,
+		recover:        nil,
+		server:         ServerConfig{},
+		serverFns:      nil,
 		tailwindcssCfg: nil,
 		postcssCfg:     nil,
 	}
@@ -123,287 +218,552 @@ func New(options ...Option) *GoSvelt {
 	opts := initOptions(options)
 
 	gs := &GoSvelt{
-		config:            opts,
-		server:            &fasthttp.Server{},
-		router:            fasthttprouter.New(),
-		pool:              sync.Pool{},
-		storePool:         sync.Pool{},
-		middlewares:       make(map[string]MiddlewareFunc),
-		svelteMiddlewares: make(map[string]SvelteMiddlewareFunc),
+		config:     opts,
+		server:     newServer(opts),
+		router:     fasthttprouter.New(),
+		pool:       sync.Pool{},
+		storePool:  sync.Pool{},
+		errHandler: opts.errorHandler,
+		done:       make(chan struct{}),
+		conns:      make(map[*websocket.Conn]struct{}),
+		builder:    newSvelteBuilder(opts.buildWorkers),
 	}
 
-	gs.router.NotFound = func(ctx *fasthttp.RequestCtx) {
-		err := fmt.Errorf(
-			http.StatusText(http.StatusNotFound),
-		)
+	gs.ctx, gs.cancel = context.WithCancel(context.Background())
 
-		opts.errorHandler(ctx, err)
+	if opts.log {
+		handler := opts.logHandler
+		if handler == nil {
+			handler = NewConsoleHandler(os.Stdout, slog.LevelInfo)
+		}
+
+		gs.logger = slog.New(handler)
+	}
+
+	if opts.dev {
+		gs.dev = gs.newDevServer()
 	}
+
+	// unrouted requests go through the error handler too
+	// (the router set the Allow header before a 405)
+	gs.router.NotFound = gs.newHandler(func(c *Context) error {
+		return ErrNotFound
+	})
+	gs.router.MethodNotAllowed = gs.newHandler(func(c *Context) error {
+		return ErrMethodNotAllowed
+	})
+
+	if opts.recover != nil {
+		recoverMiddleware := Recover(*opts.recover)
+
+		gs.Middleware("*", recoverMiddleware)
+		gs.SvelteMiddleware("*", ToSvelteMiddleware(recoverMiddleware))
+	}
+
 	gs.pool.New = gs.newContext
 	gs.storePool.New = func() interface{} { return make(Map) }
 
 	return gs
 }
 
-func (gs *GoSvelt) Start(addr string) {
-	gs.server.Handler = gs.router.Handler
+// start the server on addr, it block until the server is
+// stopped with Shutdown (then nil is returned)
+func (gs *GoSvelt) Start(addr string) error {
+	if err := gs.prepare(); err != nil {
+		return err
+	}
 
-	if gs.config.http2 {
-		http2.ConfigureServer(gs.server, http2.ServerConfig{})
+	fmt.Printf("GoSvelt is started on [:%s]\n", addr)
+
+	return gs.server.ListenAndServe(addr)
+}
+
+// same as Start but using tls with the cert and key files
+func (gs *GoSvelt) StartTLS(addr, cert, key string) error {
+	if err := gs.prepare(); err != nil {
+		return err
 	}
 
-	if _, err := os.Stat(svelteWorkdir); os.IsExist(err) {
-		err = cleanDir(svelteWorkdir)
-		if err != nil {
-			panic(err)
+	fmt.Printf("GoSvelt is started on [:%s]\n", addr)
+
+	return gs.server.ListenAndServeTLS(addr, cert, key)
+}
+
+// serve http requests from ln, it can be any listener
+// (e.g. fasthttputil.NewInmemoryListener() in tests)
+func (gs *GoSvelt) Serve(ln net.Listener) error {
+	if err := gs.prepare(); err != nil {
+		return err
+	}
+
+	fmt.Printf("GoSvelt is started on [%s]\n", ln.Addr())
+
+	return gs.server.Serve(ln)
+}
+
+// serve https requests from ln using the tls config
+func (gs *GoSvelt) ServeTLS(ln net.Listener, config *tls.Config) error {
+	// must be set before the http2 configuration
+	// which add its protocol to the tls config
+	gs.server.TLSConfig = config.Clone()
+
+	if err := gs.prepare(); err != nil {
+		return err
+	}
+
+	fmt.Printf("GoSvelt is started on [%s]\n", ln.Addr())
+
+	return gs.server.ServeTLS(ln, "", "")
+}
+
+// serve http requests on a unix domain socket (see ListenUnix)
+//
+//	like this:
+//	app.StartUnix("/run/gosvelt.sock", 0660)
+func (gs *GoSvelt) StartUnix(path string, mode os.FileMode) error {
+	ln, err := ListenUnix(path, mode)
+	if err != nil {
+		return err
+	}
+
+	return gs.Serve(ln)
+}
+
+// listen on a unix domain socket with the given file mode,
+// a stale socket file at path is removed but any other
+// kind of file is kept and an error is returned
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("gosvelt: %s already exists and is not a unix socket", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("gosvelt: cannot remove stale unix socket %s (%s)", path, err)
 		}
+
+	} else if !os.IsNotExist(err) {
+		return nil, err
 	}
 
-	fmt.Printf("GoSvelt is started on [:%s]\n", addr)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := gs.server.ListenAndServe(addr); err != nil {
-		panic(err)
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("gosvelt: cannot chmod %#o unix socket %s (%s)", mode, path, err)
 	}
+
+	return ln, nil
 }
 
-func (gs *GoSvelt) StartTLS(addr, cert, key string) {
-	gs.server.Handler = gs.router.Handler
+// setup the server and run the start hooks,
+// only the first call does something so the server
+// can serve several listeners
+func (gs *GoSvelt) prepare() error {
+	gs.prepareOnce.Do(func() {
+		gs.prepareErr = gs.setup()
+	})
+
+	return gs.prepareErr
+}
+
+func (gs *GoSvelt) setup() error {
+	if err := validateServer(gs.server); err != nil {
+		return err
+	}
+
+	gs.server.Handler = gs.serveHTTP
 
 	if gs.config.http2 {
 		http2.ConfigureServer(gs.server, http2.ServerConfig{})
 	}
 
 	if _, err := os.Stat(svelteWorkdir); os.IsExist(err) {
-		err = cleanDir(svelteWorkdir)
-		if err != nil {
-			panic(err)
+		if err := cleanDir(svelteWorkdir); err != nil {
+			return err
 		}
 	}
 
-	fmt.Printf("GoSvelt is started on [:%s]\n", addr)
+	// the svelte pages are built in the background
+	// since their registration
+	if err := gs.builder.wait(); err != nil {
+		return err
+	}
 
-	if err := gs.server.ListenAndServeTLS(addr, cert, key); err != nil {
-		panic(err)
+	if err := gs.buildBatch(); err != nil {
+		return err
 	}
-}
 
-func (gs *GoSvelt) Middleware(path string, fn MiddlewareFunc) {
-	gs.middlewares[path] = fn
-}
+	for _, hook := range gs.onStart {
+		if err := hook(); err != nil {
+			return err
+		}
+	}
 
-func (gs *GoSvelt) SvelteMiddleware(path string, fn SvelteMiddlewareFunc) {
-	gs.svelteMiddlewares[path] = fn
+	return nil
 }
 
-func (gs *GoSvelt) Get(path string, h HandlerFunc) {
-	gs.add(MGet, path, h)
-}
+// gracefully stop the server, open sse streams and ws connections
+// are closed, then in-flight requests are drained until ctx is done
+// (their contexts are canceled after) and finally the shutdown hooks
+// are called
+func (gs *GoSvelt) Shutdown(ctx context.Context) error {
+	var errs []error
 
-func (gs *GoSvelt) Post(path string, h HandlerFunc) {
-	gs.add(MPost, path, h)
-}
+	gs.shutdownOnce.Do(func() {
+		close(gs.done) // stop sse streams
 
-func (gs *GoSvelt) Put(path string, h HandlerFunc) {
-	gs.add(MPut, path, h)
-}
+		gs.closeConns()
+
+		// the requests contexts are canceled once drained,
+		// or as soon as ctx is done
+		stop := context.AfterFunc(ctx, gs.cancel)
+
+		if err := gs.server.ShutdownWithContext(ctx); err != nil {
+			errs = append(errs, err)
+		}
+
+		stop()
+		gs.cancel()
+
+		for _, hook := range gs.onShutdown {
+			if err := hook(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
 
-func (gs *GoSvelt) Delete(path string, h HandlerFunc) {
-	gs.add(MDelete, path, h)
+	return errors.Join(errs...)
 }
 
-func (gs *GoSvelt) Connect(path string, h HandlerFunc) {
-	gs.add(MConnect, path, h)
+// register a function called before the server start,
+// an error will abort the start
+func (gs *GoSvelt) OnStart(fn func() error) {
+	gs.onStart = append(gs.onStart, fn)
 }
 
-func (gs *GoSvelt) Options(path string, h HandlerFunc) {
-	gs.add(MOptions, path, h)
+// register a function called once the server is shut down
+// (e.g. to flush caches or close db pools)
+func (gs *GoSvelt) OnShutdown(fn func(ctx context.Context) error) {
+	gs.onShutdown = append(gs.onShutdown, fn)
 }
 
-func (gs *GoSvelt) Static(path, file string) {
-	gs.addStatic(MGet, path, file)
+// keep track of a ws connection to close it on shutdown,
+// false is returned if the server is already shutting down
+func (gs *GoSvelt) trackConn(conn *websocket.Conn) bool {
+	gs.connsLock.Lock()
+	defer gs.connsLock.Unlock()
+
+	select {
+	case <-gs.done:
+		return false
+
+	default:
+		gs.conns[conn] = struct{}{}
+		return true
+	}
 }
 
-// // see config
-// type SseConfig struct {
-// 	Datach  chan interface{} // needed
-// 	Closech chan struct{}    // needed
-// 	Timeout time.Duration    // optional
-// }
+func (gs *GoSvelt) untrackConn(conn *websocket.Conn) {
+	gs.connsLock.Lock()
+	defer gs.connsLock.Unlock()
 
-// sse event
-type SseEvent struct {
-	Name string // event name
-	Data string // event datas
+	delete(gs.conns, conn)
 }
 
-func (gs *GoSvelt) Sse(path string, datach chan interface{}, closech chan struct{}, fn func()) {
-	handler := func(c *fasthttp.RequestCtx) {
-		// cors headers
-		//c.Response.Header.Add("Access-Control-Allow-Origin", "*")
-		//c.Response.Header.Add("Access-Control-Allow-Headers", "Content-Type")
-		//c.Response.Header.Add("Access-Control-Allow-Credentials", "true")
+// send a close message to every open ws connection and close them
+func (gs *GoSvelt) closeConns() {
+	gs.connsLock.Lock()
+	defer gs.connsLock.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown")
 
-		// sse headers
-		c.Response.Header.Add("Content-Type", "text/event-stream")
-		c.Response.Header.Add("Transfer-Encoding", "chunked")
-		c.Response.Header.Add("Cache-Control", "no-cache")
-		c.Response.Header.Add("Connection", "keep-alive")
+	for conn := range gs.conns {
+		conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		conn.Close()
 
-		// write body stream
-		c.Response.SetBodyStream(
-			fasthttp.NewStreamReader(
-				func(w *bufio.Writer) {
-					flush := func() {
-						if err := w.Flush(); err != nil {
-							fmt.Printf("sse: flushing error: %v. closing http connection\n", err)
-							return
-						}
-					}
+		delete(gs.conns, conn)
+	}
+}
 
-					//Loop:
-					for {
-						select {
-						case <-closech:
-							close(datach)
+// register a middleware for every route whose path starts with path
+// ("*" matches every route)
+//
+// NOTE: middlewares are chained in registration order (the first one
+// registered is the outermost) and are resolved when a route is added,
+// so they must be registered before the routes they should wrap
+func (gs *GoSvelt) Middleware(path string, fn MiddlewareFunc) {
+	gs.middlewares = append(gs.middlewares, middleware{path: path, fn: fn})
+}
 
-							//c.Res().Header.SetConnectionClose()
+// same as Middleware but for svelte handlers
+func (gs *GoSvelt) SvelteMiddleware(path string, fn SvelteMiddlewareFunc) {
+	gs.svelteMiddlewares = append(gs.svelteMiddlewares, svelteMiddleware{path: path, fn: fn})
+}
 
-							return
+func (gs *GoSvelt) Get(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MGet, path, h, mws)
+}
 
-						case msg := <-datach:
-							switch m := msg.(type) {
-							case string:
-								fmt.Fprintf(w, "data: %s\n\n", m)
+func (gs *GoSvelt) Post(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MPost, path, h, mws)
+}
 
-							case SseEvent:
-								fmt.Fprintf(w, "event: %s\n\n", m.Name)
-								fmt.Fprintf(w, "data: %s\n\n", m.Data)
+func (gs *GoSvelt) Put(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MPut, path, h, mws)
+}
 
-							default: // we don't care
-								fmt.Fprintf(w, "data: %s\n\n", m)
-							}
+func (gs *GoSvelt) Delete(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MDelete, path, h, mws)
+}
 
-							flush()
-						}
-					}
-				},
-			), -1,
-		)
+func (gs *GoSvelt) Connect(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MConnect, path, h, mws)
+}
 
-		// start user func
-		go fn()
-	}
+func (gs *GoSvelt) Options(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return gs.add(MOptions, path, h, mws)
+}
 
-	gs.router.Handle(MGet, path, handler)
+func (gs *GoSvelt) Static(path, file string) *Route {
+	return gs.add(MGet, path, staticHandler(file), nil).Hide()
 }
 
-// help to server Svelte files to client
+// sse event
+type SseEvent struct {
+	Name string // event name
+	Data string // event datas
+}
+
+func (gs *GoSvelt) Sse(path string, datach chan interface{}, closech chan struct{}, fn func()) *Route {
+	return gs.add(MGet, path, sseHandler(datach, closech, fn), nil).Hide()
+}
+
+// help to server Svelte files to client,
+// use WithMiddlewares to add route scoped svelte middlewares
 func (gs *GoSvelt) Svelte(
 	path, svelteFile string,
 	handlerFn SvelteHandlerFunc,
 	options ...SvelteOption,
-) {
-	gs.addSvelte(
+) *Route {
+	return gs.addSvelte(
 		path,       // url path
 		svelteFile, // svelte file
 		handlerFn,
+		nil,
 		options...,
 	)
 }
 
-func (gs *GoSvelt) add(method, path string, h HandlerFunc) {
-	gs.router.Handle(method, path, gs.newHandler(h))
+// mws are the route scoped middlewares (e.g. group ones),
+// they are chained inside the global middlewares
+func (gs *GoSvelt) add(method, path string, h HandlerFunc, mws []MiddlewareFunc) *Route {
+	h = gs.chain(path, applyMiddlewares(h, mws))
+
+	gs.handle(method, path, gs.newHandler(h))
+
+	return gs.addRoute(method, path)
 }
 
 func (gs *GoSvelt) addSvelte(
 	path,
 	svelteFile string,
 	handlerFn SvelteHandlerFunc,
+	mws []MiddlewareFunc,
 	options ...SvelteOption,
-) {
-	// compile svelte file to compFile
-	buildId, buildFolder, err := BuildSvelte(
-		svelteFile,
-		options...,
-	)
-	if err != nil {
-		log.Fatal(err)
+) *Route {
+	opts := new(SvelteOptions)
+
+	for _, opt := range options {
+		opt(opts)
 	}
 
-	jsBundleUrl, err := url.JoinPath(path, buildId, "bundle.js")
-	if err != nil {
-		panic(err)
+	page := &sveltePage{
+		path:    path,
+		file:    svelteFile,
+		options: options,
+		opts:    opts,
 	}
 
-	cssBundleUrl, err := url.JoinPath(path, buildId, "bundle.css")
-	if err != nil {
-		panic(err)
+	// the ssr pool is created once, before the builds, its
+	// error is returned by the start like the build errors
+	if opts.ssr {
+		if _, err := gs.ssrPool(); err != nil {
+			gs.builder.run(func() error {
+				return fmt.Errorf("svelte: cannot render %s on the server (%w)", svelteFile, err)
+			})
+
+			return gs.addRoute(MGet, path).Hide()
+		}
+	}
+
+	// the template is checked with the builds, so its
+	// error is returned by the start
+	if opts.template != nil {
+		template := *opts.template
+
+		gs.builder.run(func() error {
+			return checkSvelteTemplate(template)
+		})
+	}
+
+	// the page is built in the background (or with the other pages
+	// in batch mode), the start wait for it, with the typescript
+	// client of the routes registered so far
+	if gs.config.batch && gs.dev == nil {
+		gs.batch = append(gs.batch, page)
+
+	} else {
+		apiClient := gs.TypeScript()
+
+		gs.builder.run(func() error {
+			return gs.buildPage(page, apiClient)
+		})
 	}
 
-	// this map gives the js and css path
-	svelteMap := Map{
-		"js":  jsBundleUrl,
-		"css": cssBundleUrl,
+	chain := func(h SvelteHandlerFunc) SvelteHandlerFunc {
+		return gs.svelteChain(path, applySvelteMiddlewares(
+			applySvelteRouteMiddlewares(h, opts.middlewares),
+			mws,
+		))
+	}
+
+	front := gs.newFrontHandler(chain(handlerFn), page.svelteMap)
+
+	// the page html is cached (SSG / ISR), except in dev mode where
+	// the page can be rebuilt, it's rendered without the middlewares
+	// which still run on every request (e.g. auth) before the cache
+	if (opts.prerender || opts.revalidate > 0) && gs.dev == nil {
+		static := gs.newStaticPage(path, page, gs.newFrontHandler(handlerFn, page.svelteMap), opts)
+
+		front = gs.newFrontHandler(chain(func(c *Context, svelte Map) error {
+			static.serve(c.fasthttpCtx)
+			return nil
+		}), page.svelteMap)
 	}
 
 	// this will handle the main route
-	gs.router.Handle(
-		MGet,
-		path,
-		gs.newFrontHandler(handlerFn, svelteMap),
-	)
+	gs.handle(MGet, path, front)
 
-	// this will handle the js bundle file
-	gs.addStatic(
-		MGet,
-		svelteMap["js"].(string),
-		filepath.Join(buildFolder, "bundle.js"),
-	)
-	// this will handle the css bundle file
-	gs.addStatic(
-		MGet,
-		svelteMap["css"].(string),
-		filepath.Join(buildFolder, "bundle.css"),
-	)
+	if gs.dev != nil {
+		gs.dev.addPage(page)
+	}
+
+	return gs.addRoute(MGet, path).Hide()
+}
+
+// register h on the router, the route pattern
+// is kept in the request user values
+func (gs *GoSvelt) handle(method, path string, h fasthttp.RequestHandler) {
+	gs.router.Handle(method, path, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(routeKey{}, path)
+		h(ctx)
+	})
+}
+
+// the server handler, every request go through it
+func (gs *GoSvelt) serveHTTP(ctx *fasthttp.RequestCtx) {
+	if gs.logger == nil {
+		setRequestID(ctx)
+		gs.route(ctx)
+
+		return
+	}
+
+	start := time.Now()
+	method, path := string(ctx.Method()), string(ctx.Path())
+
+	setRequestID(ctx)
+	gs.route(ctx)
+
+	gs.logRequest(ctx, method, path, start)
+}
+
+// serve the svelte bundles, or route the request
+func (gs *GoSvelt) route(ctx *fasthttp.RequestCtx) {
+	if ctx.IsGet() || ctx.IsHead() {
+		if file, ok := gs.assets.get(string(ctx.Path())); ok {
+			ctx.SetUserValue(routeKey{}, string(ctx.Path()))
+			ctx.SendFile(file)
+
+			// the urls have the build id, the files never change
+			if ctx.Response.StatusCode() == http.StatusOK {
+				ctx.SetContentType(assetType(file))
+				ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "public, max-age=31536000, immutable")
+			}
+
+			return
+		}
+	}
+
+	gs.router.Handler(ctx)
+}
+
+// this wrap h with every middleware matching the route path,
+// the first registered middleware being the outermost one
+func (gs *GoSvelt) chain(path string, h HandlerFunc) HandlerFunc {
+	for i := len(gs.middlewares) - 1; i >= 0; i-- {
+		if mid := gs.middlewares[i]; mid.match(path) {
+			h = mid.fn(h)
+		}
+	}
+
+	return h
 }
 
-func (gs *GoSvelt) addStatic(method, path, file string) {
-	gs.router.Handle(method, path, func(ctx *fasthttp.RequestCtx) { ctx.SendFile(file) })
+// same as chain but for svelte handlers
+func (gs *GoSvelt) svelteChain(path string, h SvelteHandlerFunc) SvelteHandlerFunc {
+	for i := len(gs.svelteMiddlewares) - 1; i >= 0; i-- {
+		if mid := gs.svelteMiddlewares[i]; mid.match(path) {
+			h = mid.fn(h)
+		}
+	}
+
+	return h
 }
 
 // this create an fasthttp handler
 // with an front handler and an svelte path
-func (gs *GoSvelt) newFrontHandler(handlerFn SvelteHandlerFunc, svelte Map) fasthttp.RequestHandler {
+func (gs *GoSvelt) newFrontHandler(handlerFn SvelteHandlerFunc, svelteMap func() Map) fasthttp.RequestHandler {
 	return func(bctx *fasthttp.RequestCtx) {
+		svelte := svelteMap()
+
 		ctx := gs.pool.Get().(*Context) // get context from the pool
 
 		// make an new context for fonction
 		// using fasthttp request context
 		ctx.update(bctx)
 
-		defer ctx.reset()      // reset the context with nil values
-		defer gs.pool.Put(ctx) // put the context back in the pool after the reset
+		// reset the context with nil values and then put it
+		// back in the pool (defers run in reverse order)
+		defer gs.pool.Put(ctx)
+		defer ctx.reset()
 
-		// middlewares
-		if len(gs.svelteMiddlewares) != 0 {
-			for path, mid := range gs.svelteMiddlewares {
-				if strings.HasPrefix(ctx.Path(), path) || path == "*" {
-					mid(handlerFn)
-				}
-			}
+		// every request get its own svelte map, so
+		// the handler can add its values and props
+		reqSvelte := make(Map, len(svelte)+1)
+		for key, value := range svelte {
+			reqSvelte[key] = value
 		}
+		reqSvelte["props"] = svelteProps{}
+		bindSSR(ctx, reqSvelte)
+
+		ctx.svelte = reqSvelte
 
 		// if there are no errors handle the req
 		// else use the default error handler
-		if err := handlerFn(ctx, svelte); err != nil {
-			gs.errHandler(bctx, err)
+		if err := handlerFn(ctx, reqSvelte); err != nil {
+			gs.errHandler(ctx, err)
 		}
 	}
 }
 
 // most important function,
 // goal is to convert HandlerFunc to fasthttp.RequestHandler
-// and to handle middlewares
+// (middlewares are already chained in h)
 func (gs *GoSvelt) newHandler(h HandlerFunc) fasthttp.RequestHandler {
 	return func(bctx *fasthttp.RequestCtx) {
 		// make an new context for fonction
@@ -411,19 +771,10 @@ func (gs *GoSvelt) newHandler(h HandlerFunc) fasthttp.RequestHandler {
 		ctx := gs.pool.Get().(*Context)
 		ctx.update(bctx)
 
-		// middlewares
-		if len(gs.middlewares) != 0 {
-			for p, mid := range gs.middlewares {
-				if strings.HasPrefix(ctx.Path(), p) || p == "*" {
-					mid(h)
-				}
-			}
-		}
-
 		// if there are no errors handle the req
 		// else use the default error handler
 		if err := h(ctx); err != nil {
-			gs.errHandler(bctx, err)
+			gs.errHandler(ctx, err)
 		}
 
 		// reset the context with nil values
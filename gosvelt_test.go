@@ -0,0 +1,26 @@
+package gosvelt
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// run a request through the app like the server does, the headers
+// are key value pairs (e.g. "Content-Type", "application/json")
+func serveRequest(gs *GoSvelt, method, uri, body string, headers ...string) *fasthttp.Response {
+	var ctx fasthttp.RequestCtx
+
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	ctx.Request.SetBodyString(body)
+
+	for i := 0; i+1 < len(headers); i += 2 {
+		ctx.Request.Header.Add(headers[i], headers[i+1])
+	}
+
+	gs.serveHTTP(&ctx)
+
+	res := new(fasthttp.Response)
+	ctx.Response.CopyTo(res)
+
+	return res
+}
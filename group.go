@@ -0,0 +1,129 @@
+package gosvelt
+
+import (
+	"path"
+	"strings"
+)
+
+// Group is a set of routes sharing a path prefix and
+// scoped middlewares, groups can be nested
+type Group struct {
+	gosvelt     *GoSvelt
+	prefix      string
+	middlewares []MiddlewareFunc
+}
+
+// create a route group under prefix, mws will only wrap
+// the routes of this group (and of its sub groups)
+func (gs *GoSvelt) Group(prefix string, mws ...MiddlewareFunc) *Group {
+	return &Group{
+		gosvelt:     gs,
+		prefix:      joinPath(prefix, ""),
+		middlewares: mws,
+	}
+}
+
+// create a sub group, it inherit the prefix and the middlewares
+// of its parent
+func (g *Group) Group(prefix string, mws ...MiddlewareFunc) *Group {
+	middlewares := make([]MiddlewareFunc, 0, len(g.middlewares)+len(mws))
+	middlewares = append(middlewares, g.middlewares...)
+	middlewares = append(middlewares, mws...)
+
+	return &Group{
+		gosvelt:     g.gosvelt,
+		prefix:      joinPath(g.prefix, prefix),
+		middlewares: middlewares,
+	}
+}
+
+// add middlewares to the group
+//
+// NOTE: like global middlewares, they only wrap the routes
+// registered after them
+func (g *Group) Use(mws ...MiddlewareFunc) {
+	g.middlewares = append(g.middlewares, mws...)
+}
+
+func (g *Group) Get(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MGet, path, h, mws...)
+}
+
+func (g *Group) Post(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MPost, path, h, mws...)
+}
+
+func (g *Group) Put(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MPut, path, h, mws...)
+}
+
+func (g *Group) Delete(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MDelete, path, h, mws...)
+}
+
+func (g *Group) Connect(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MConnect, path, h, mws...)
+}
+
+func (g *Group) Options(path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.add(MOptions, path, h, mws...)
+}
+
+func (g *Group) Static(path, file string) *Route {
+	return g.add(MGet, path, staticHandler(file)).Hide()
+}
+
+func (g *Group) Sse(path string, datach chan interface{}, closech chan struct{}, fn func()) *Route {
+	return g.add(MGet, path, sseHandler(datach, closech, fn)).Hide()
+}
+
+// same as GoSvelt.Svelte, the group middlewares run inside
+// the global svelte middlewares
+func (g *Group) Svelte(
+	path, svelteFile string,
+	handlerFn SvelteHandlerFunc,
+	options ...SvelteOption,
+) *Route {
+	return g.gosvelt.addSvelte(
+		joinPath(g.prefix, path),
+		svelteFile,
+		handlerFn,
+		g.scoped(),
+		options...,
+	)
+}
+
+func (g *Group) add(method, path string, h HandlerFunc, mws ...MiddlewareFunc) *Route {
+	return g.gosvelt.add(method, joinPath(g.prefix, path), h, g.scoped(mws...))
+}
+
+// copy of the group middlewares followed by the route ones,
+// so a later Use won't change the already registered routes
+func (g *Group) scoped(mws ...MiddlewareFunc) []MiddlewareFunc {
+	scoped := make([]MiddlewareFunc, 0, len(g.middlewares)+len(mws))
+	scoped = append(scoped, g.middlewares...)
+
+	return append(scoped, mws...)
+}
+
+// join a group prefix and a route path, the result is cleaned
+// and always starts with a slash, a trailing slash is kept
+//
+//	like this:
+//	joinPath("/api/", "/users") // "/api/users"
+//	joinPath("api", "users/")   // "/api/users/"
+//	joinPath("/api", "")        // "/api"
+func joinPath(prefix, p string) string {
+	joined := path.Join("/", prefix, p)
+
+	trailing := p
+	if p == "" {
+		trailing = prefix
+	}
+
+	if strings.HasSuffix(trailing, "/") && joined != "/" {
+		joined += "/"
+	}
+
+	return joined
+}
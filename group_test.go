@@ -0,0 +1,44 @@
+package gosvelt
+
+import "testing"
+
+func TestJoinPath(t *testing.T) {
+	for _, tt := range []struct {
+		prefix, path, want string
+	}{
+		{"/api", "/users", "/api/users"},
+		{"/api/", "/users", "/api/users"},
+		{"api", "users", "/api/users"},
+		{"/api", "users/", "/api/users/"},
+		{"/api", "", "/api"},
+		{"/api/", "", "/api/"},
+		{"", "/", "/"},
+		{"/", "/", "/"},
+		{"", "", "/"},
+		{"/blog", "/", "/blog/"},
+		{"/api//v1", "/:id", "/api/v1/:id"},
+		{"/files", "/*filepath", "/files/*filepath"},
+	} {
+		if got := joinPath(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinPath(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGroupPrefix(t *testing.T) {
+	gs := New()
+
+	api := gs.Group("api/")
+	api.Get("/users", String("users"))
+	api.Group("v1").Get("items", String("items"))
+
+	for uri, body := range map[string]string{
+		"/api/users":    "users",
+		"/api/v1/items": "items",
+	} {
+		res := serveRequest(gs, MGet, uri, "")
+		if res.StatusCode() != 200 || string(res.Body()) != body {
+			t.Errorf("%s: %d %q", uri, res.StatusCode(), res.Body())
+		}
+	}
+}
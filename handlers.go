@@ -2,21 +2,61 @@ package gosvelt
 
 import (
 	"fmt"
-
-	"github.com/valyala/fasthttp"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
 )
 
-var defaultErrorHandler = func(c *fasthttp.RequestCtx, err error) {
-	fmt.Printf("[%s] -> %v\n", c.Path(), err)
+// the default error handler send the http error status and
+// render it as json, html or text depending on the Accept header
+var defaultErrorHandler = func(c *Context, err error) {
+	he := toHTTPError(err)
+
+	level := slog.LevelWarn
+	if he.Code >= 500 {
+		level = slog.LevelError
+	}
+
+	c.gosvelt.errorLogger().LogAttrs(c.Ctx, level, "request error",
+		slog.String("path", c.Path()),
+		slog.String("request_id", RequestIDFromContext(c.Ctx)),
+		slog.Any("error", err),
+	)
+
+	c.Res().ResetBody()
+
+	switch negotiateType(string(c.Req().Header.Peek("Accept")), MAppJSON, MTextHTML, MTextPlain) {
+	case MAppJSON:
+		if err := c.Json(he.Code, he); err != nil {
+			c.Json(he.Code, Map{"status": he.Code, "error": he.Message})
+		}
 
-	c.Write([]byte(err.Error()))
+	case MTextHTML:
+		page := fmt.Sprintf(
+			"<!DOCTYPE html><html><head><title>%d %s</title></head><body><h1>%d %s</h1>",
+			he.Code, html.EscapeString(http.StatusText(he.Code)),
+			he.Code, html.EscapeString(he.Message),
+		)
+		if he.Details != nil {
+			page += fmt.Sprintf("<pre>%s</pre>", html.EscapeString(fmt.Sprint(he.Details)))
+		}
+		page += "</body></html>"
+
+		c.SetCType(MTextHtmlUTF8)
+		c.SetStatusCode(he.Code)
+		c.Write([]byte(page))
+
+	default:
+		c.Text(he.Code, he.Message)
+	}
 }
 
 type HandlerFunc func(c *Context) error
 type MiddlewareFunc func(next HandlerFunc) HandlerFunc
 type SvelteMiddlewareFunc func(next SvelteHandlerFunc) SvelteHandlerFunc
 type SvelteHandlerFunc func(c *Context, svelte Map) error
-type ErrorHandlerFunc func(c *fasthttp.RequestCtx, err error)
+type ErrorHandlerFunc func(c *Context, err error)
 
 func Status(code int) HandlerFunc {
 	return func(c *Context) error {
@@ -36,3 +76,80 @@ func Json(j interface{}) HandlerFunc {
 		return c.Json(200, j)
 	}
 }
+
+// serve a file from the disk
+func staticHandler(file string) HandlerFunc {
+	return func(c *Context) error {
+		c.fasthttpCtx.SendFile(file)
+		return nil
+	}
+}
+
+// stream datach to the client until closech is closed
+func sseHandler(datach chan interface{}, closech chan struct{}, fn func()) HandlerFunc {
+	return func(c *Context) error {
+		return c.Sse(datach, closech, fn)
+	}
+}
+
+// middleware registered with a path prefix
+type middleware struct {
+	path string
+	fn   MiddlewareFunc
+}
+
+// svelte middleware registered with a path prefix
+type svelteMiddleware struct {
+	path string
+	fn   SvelteMiddlewareFunc
+}
+
+func (m middleware) match(path string) bool {
+	return matchPrefix(m.path, path)
+}
+
+func (m svelteMiddleware) match(path string) bool {
+	return matchPrefix(m.path, path)
+}
+
+// true if the route path is covered by the middleware prefix
+func matchPrefix(prefix, path string) bool {
+	return prefix == "*" || strings.HasPrefix(path, prefix)
+}
+
+// wrap h with mws, the first one being the outermost
+func applyMiddlewares(h HandlerFunc, mws []MiddlewareFunc) HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// wrap a svelte handler with route scoped middlewares, the svelte
+// map given by the outer svelte middlewares is kept in the context
+// while the inner chain is running
+func applySvelteMiddlewares(h SvelteHandlerFunc, mws []MiddlewareFunc) SvelteHandlerFunc {
+	if len(mws) == 0 {
+		return h
+	}
+
+	inner := applyMiddlewares(func(c *Context) error {
+		return h(c, c.svelte)
+	}, mws)
+
+	return func(c *Context, svelte Map) error {
+		c.svelte = svelte
+		return inner(c)
+	}
+}
+
+// wrap a svelte handler with its route middlewares,
+// the first one being the outermost
+func applySvelteRouteMiddlewares(h SvelteHandlerFunc, mws []SvelteMiddlewareFunc) SvelteHandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
@@ -0,0 +1,44 @@
+//go:build !unix
+
+package gosvelt
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const (
+	lockFilePoll  = 100 * time.Millisecond
+	lockFileStale = 10 * time.Minute // left by a dead process
+)
+
+// lock a file across processes by creating it, shared locks
+// can't be done on this platform and are only held in process
+func lockFile(path string, shared bool) (unlock func(), err error) {
+	if shared {
+		return func() {}, nil
+	}
+
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+
+			return func() {
+				os.Remove(path)
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockFileStale {
+			os.Remove(path)
+			continue
+		}
+
+		time.Sleep(lockFilePoll)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package gosvelt
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lock a file across processes (flock), the lock is
+// released if the process dies
+func lockFile(path string, shared bool) (unlock func(), err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+
+	fd := int(file.Fd())
+
+	for {
+		err = syscall.Flock(fd, how)
+		if !errors.Is(err, syscall.EINTR) {
+			break
+		}
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(fd, syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,198 @@
+package gosvelt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// request id header, read from the request and set on the response
+const HeaderRequestID = "X-Request-ID"
+
+// user value keys set on every request
+type (
+	routeKey     struct{}
+	requestIDKey struct{}
+)
+
+// give the request an id, the client one is kept if it looks sane
+func setRequestID(ctx *fasthttp.RequestCtx) string {
+	id := string(ctx.Request.Header.Peek(HeaderRequestID))
+
+	if id == "" || len(id) > 128 || strings.ContainsAny(id, " \t\r\n") {
+		id = newRequestID()
+	}
+
+	ctx.SetUserValue(requestIDKey{}, id)
+	ctx.Response.Header.Set(HeaderRequestID, id)
+
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// the logger of the errors and recovered panics, it falls back
+// to the default slog logger when the access log is disabled
+func (gs *GoSvelt) errorLogger() *slog.Logger {
+	if gs.logger == nil {
+		return slog.Default()
+	}
+
+	return gs.logger
+}
+
+// write the access log of a served request
+// (method and path are read before serving as handlers like
+// SendFile rewrite the request uri)
+func (gs *GoSvelt) logRequest(ctx *fasthttp.RequestCtx, method, path string, start time.Time) {
+	status := ctx.Response.StatusCode()
+
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+
+	size := len(ctx.Response.Body())
+	if ctx.Response.IsBodyStream() {
+		size = max(ctx.Response.Header.ContentLength(), 0)
+	}
+
+	route, _ := ctx.UserValue(routeKey{}).(string)
+	requestID, _ := ctx.UserValue(requestIDKey{}).(string)
+
+	gs.logger.LogAttrs(context.Background(), level, "request",
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.String("route", route),
+		slog.Int("status", status),
+		slog.Int("bytes", size),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("ip", ctx.RemoteIP().String()),
+		slog.String("request_id", requestID),
+	)
+}
+
+// ConsoleHandler is a slog handler writing colored
+// human readable lines, made for development
+//
+//	like this:
+//	gosvelt.WithLogHandler(gosvelt.NewConsoleHandler(os.Stdout, slog.LevelDebug))
+type ConsoleHandler struct {
+	w      io.Writer
+	lock   *sync.Mutex
+	level  slog.Leveler
+	attrs  string // preformatted attrs
+	prefix string // group prefix
+}
+
+func NewConsoleHandler(w io.Writer, level slog.Leveler) *ConsoleHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return &ConsoleHandler{
+		w:     w,
+		lock:  &sync.Mutex{},
+		level: level,
+	}
+}
+
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	color := "\033[36m" // cyan
+	switch {
+	case r.Level >= slog.LevelError:
+		color = "\033[31m" // red
+	case r.Level >= slog.LevelWarn:
+		color = "\033[33m" // yellow
+	case r.Level < slog.LevelInfo:
+		color = "\033[90m" // grey
+	}
+
+	fmt.Fprintf(&b, "\033[90m%s\033[0m %s%-5s\033[0m %s%s",
+		r.Time.Format("15:04:05.000"), color, r.Level, r.Message, h.attrs,
+	)
+
+	r.Attrs(func(a slog.Attr) bool {
+		writeConsoleAttr(&b, h.prefix, a)
+		return true
+	})
+
+	b.WriteByte('\n')
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	_, err := io.WriteString(h.w, b.String())
+
+	return err
+}
+
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+
+	for _, a := range attrs {
+		writeConsoleAttr(&b, h.prefix, a)
+	}
+
+	h2 := *h
+	h2.attrs += b.String()
+
+	return &h2
+}
+
+func (h *ConsoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.prefix += name + "."
+
+	return &h2
+}
+
+func writeConsoleAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+
+		for _, ga := range a.Value.Group() {
+			writeConsoleAttr(b, prefix, ga)
+		}
+
+		return
+	}
+
+	fmt.Fprintf(b, " \033[90m%s%s=\033[0m%s", prefix, a.Key, a.Value)
+}
@@ -0,0 +1,168 @@
+package gosvelt
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// a chunk of the vite build manifest
+type viteChunk struct {
+	File           string   `json:"file"`
+	Src            string   `json:"src,omitempty"`
+	IsEntry        bool     `json:"isEntry,omitempty"`
+	Imports        []string `json:"imports,omitempty"`
+	DynamicImports []string `json:"dynamicImports,omitempty"`
+	Css            []string `json:"css,omitempty"`
+	Assets         []string `json:"assets,omitempty"`
+}
+
+// the vite build manifest, by source file
+// (e.g. src/p0/main.ts) or chunk name
+type viteManifest map[string]viteChunk
+
+// read the manifest of a vite build folder
+// (in .vite since vite 5)
+func readViteManifest(dist string) (viteManifest, error) {
+	for _, file := range []string{
+		filepath.Join(dist, ".vite", "manifest.json"),
+		filepath.Join(dist, "manifest.json"),
+	} {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var manifest viteManifest
+
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("svelte: invalid vite manifest %s (%s)", file, err)
+		}
+
+		return manifest, nil
+	}
+
+	return nil, fmt.Errorf("svelte: could not found the vite manifest in %s, builder error", dist)
+}
+
+// the css files of a chunk and of its static imports
+func (m viteManifest) css(chunk viteChunk) []string {
+	var files []string
+
+	seen := make(map[string]bool)
+
+	var walk func(chunk viteChunk)
+	walk = func(chunk viteChunk) {
+		for _, name := range chunk.Imports {
+			if !seen[name] {
+				seen[name] = true
+				walk(m[name])
+			}
+		}
+
+		for _, file := range chunk.Css {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	walk(chunk)
+
+	return files
+}
+
+// the js files statically imported by a chunk (e.g. the
+// shared chunks of the svelte runtime), to preload them
+func (m viteManifest) imports(chunk viteChunk) []string {
+	var files []string
+
+	seen := make(map[string]bool)
+
+	var walk func(chunk viteChunk)
+	walk = func(chunk viteChunk) {
+		for _, name := range chunk.Imports {
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			if imported, ok := m[name]; ok {
+				files = append(files, imported.File)
+				walk(imported)
+			}
+		}
+	}
+
+	walk(chunk)
+
+	return files
+}
+
+// copy the emitted files of a vite build (its assets folder)
+// and its manifest to the output folder
+func copyViteBuild(dist, outputFolder string) (viteManifest, error) {
+	manifest, err := readViteManifest(dist)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := copyDir(filepath.Join(dist, "assets"), filepath.Join(outputFolder, "assets")); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(outputFolder, "manifest.json"), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// write the css of a chunk and of its imports in one file,
+// the file is empty if there is no css
+func writeViteCss(dist string, manifest viteManifest, chunk viteChunk, file string) error {
+	var css []byte
+
+	for _, name := range manifest.css(chunk) {
+		data, err := os.ReadFile(filepath.Join(dist, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+
+		css = append(append(css, data...), '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, css, 0644)
+}
+
+// the modulepreload links of the "preload" value of the svelte map
+//
+//	like this:
+//	<head>&{preload}<script type='module' src='&{js}'></script></head>
+func modulePreloads(urls []string) string {
+	var links strings.Builder
+
+	for _, url := range urls {
+		fmt.Fprintf(&links, `<link rel="modulepreload" href="%s">`, html.EscapeString(url))
+	}
+
+	return links.String()
+}
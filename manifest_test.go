@@ -0,0 +1,186 @@
+package gosvelt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the manifest of a vite build with two pages sharing
+// the svelte runtime chunk, as written by vite 5
+const testViteManifest = `{
+	"src/p0/main.ts": {
+		"file": "assets/p0-a1.js",
+		"isEntry": true,
+		"imports": ["_runtime.js", "_button.js"],
+		"dynamicImports": ["src/p0/Lazy.svelte"],
+		"css": ["assets/p0-a1.css"]
+	},
+	"src/p1/main.ts": {
+		"file": "assets/p1-b2.js",
+		"isEntry": true,
+		"imports": ["_runtime.js", "_missing.js"]
+	},
+	"_runtime.js": {"file": "assets/runtime-c3.js"},
+	"_button.js": {
+		"file": "assets/button-d4.js",
+		"imports": ["_runtime.js"],
+		"css": ["assets/button-d4.css", "assets/p0-a1.css"]
+	},
+	"src/p0/Lazy.svelte": {
+		"file": "assets/Lazy-e5.js",
+		"css": ["assets/Lazy-e5.css"]
+	}
+}`
+
+// write the files of a fake vite build folder
+func writeViteDist(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dist := t.TempDir()
+
+	for name, content := range files {
+		file := filepath.Join(dist, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dist
+}
+
+func TestViteBuild(t *testing.T) {
+	dist := writeViteDist(t, map[string]string{
+		".vite/manifest.json":  testViteManifest,
+		"assets/p0-a1.js":      "page 0",
+		"assets/p0-a1.css":     ".page{}",
+		"assets/runtime-c3.js": "runtime",
+		"assets/button-d4.js":  "button",
+		"assets/button-d4.css": ".button{}",
+		"assets/Lazy-e5.js":    "lazy",
+		"assets/Lazy-e5.css":   ".lazy{}",
+		"index.html":           "not copied",
+	})
+
+	out := t.TempDir()
+
+	manifest, err := copyViteBuild(dist, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every emitted file is served, the lazy chunks included
+	for _, name := range []string{"assets/p0-a1.js", "assets/Lazy-e5.js", "assets/Lazy-e5.css", "manifest.json"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s is not copied", name)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err == nil {
+		t.Error("index.html is copied")
+	}
+
+	// the copied manifest can be read again
+	if copied, err := readViteManifest(out); err != nil || len(copied) != len(manifest) {
+		t.Errorf("copied manifest: %d chunks, %v", len(copied), err)
+	}
+
+	page := manifest["src/p0/main.ts"]
+
+	// the css of the static imports, once and in order,
+	// without the css of the lazy chunks
+	css := filepath.Join(out, "p0", "bundle.css")
+	if err := writeViteCss(dist, manifest, page, css); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(css); string(data) != ".button{}\n.page{}\n" {
+		t.Errorf("p0 css = %q", data)
+	}
+
+	if got := strings.Join(manifest.imports(page), " "); got != "assets/runtime-c3.js assets/button-d4.js" {
+		t.Errorf("p0 imports = %s", got)
+	}
+
+	// a missing chunk is ignored, an empty css file is written
+	other := manifest["src/p1/main.ts"]
+
+	if got := strings.Join(manifest.imports(other), " "); got != "assets/runtime-c3.js" {
+		t.Errorf("p1 imports = %s", got)
+	}
+
+	css = filepath.Join(out, "p1", "bundle.css")
+	if err := writeViteCss(dist, manifest, other, css); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(css); err != nil || len(data) != 0 {
+		t.Errorf("p1 css = %q, %v", data, err)
+	}
+}
+
+func TestReadViteManifestErrors(t *testing.T) {
+	// vite 4 writes it at the root of the build folder
+	dist := writeViteDist(t, map[string]string{"manifest.json": testViteManifest})
+	if _, err := readViteManifest(dist); err != nil {
+		t.Errorf("vite 4 manifest: %v", err)
+	}
+
+	dist = writeViteDist(t, map[string]string{".vite/manifest.json": `{"index.html":`})
+	if _, err := readViteManifest(dist); err == nil || !strings.Contains(err.Error(), "invalid vite manifest") {
+		t.Errorf("invalid manifest: %v", err)
+	}
+
+	if _, err := readViteManifest(t.TempDir()); err == nil {
+		t.Error("a missing manifest must fail")
+	}
+}
+
+func TestModulePreloads(t *testing.T) {
+	got := modulePreloads([]string{"/_gosvelt/b1/assets/a.js", `/b".js`})
+	want := `<link rel="modulepreload" href="/_gosvelt/b1/assets/a.js"><link rel="modulepreload" href="/b&#34;.js">`
+
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	if modulePreloads(nil) != "" {
+		t.Error("no url must give no link")
+	}
+}
+
+func TestCheckSvelteTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, template := range map[string]string{
+		"module.html":   `<head>&{preload}<script type='module' src='&{js}'></script></head>`,
+		"unquoted.html": `<script src="&{js}" type=module defer></script>`,
+		"classic.html":  `<head><script src='&{js}'></script></head>`,
+		"other.html":    `<script src="/analytics.js"></script><script type="module" src="&{js}"></script>`,
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(template), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := checkSvelteTemplate(file)
+
+		if name == "classic.html" {
+			if err == nil || !strings.Contains(err.Error(), file) {
+				t.Errorf("%s: error = %v", name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if err := checkSvelteTemplate(filepath.Join(dir, "missing.html")); err == nil {
+		t.Error("a missing template must fail")
+	}
+}
@@ -0,0 +1,91 @@
+package gosvelt
+
+import (
+	"strings"
+	"testing"
+)
+
+// a middleware appending its name to the trace before and
+// after the next handler
+func traceMiddleware(trace *[]string, name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			*trace = append(*trace, name)
+			err := next(c)
+			*trace = append(*trace, "/"+name)
+
+			return err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+
+	gs := New()
+
+	gs.Middleware("*", traceMiddleware(&trace, "global"))
+	gs.Middleware("/api", traceMiddleware(&trace, "api"))
+	gs.Middleware("/admin", traceMiddleware(&trace, "admin"))
+
+	api := gs.Group("/api", traceMiddleware(&trace, "group"))
+	api.Use(traceMiddleware(&trace, "use"))
+
+	users := api.Group("/users", traceMiddleware(&trace, "sub"))
+
+	handler := func(c *Context) error {
+		trace = append(trace, "handler")
+		return nil
+	}
+
+	users.Get("/:id", handler, traceMiddleware(&trace, "route"))
+	gs.Get("/health", handler)
+
+	// registered after the routes, it wraps none of them
+	gs.Middleware("*", traceMiddleware(&trace, "late"))
+	api.Use(traceMiddleware(&trace, "late-use"))
+
+	for _, tt := range []struct {
+		uri   string
+		trace string
+	}{
+		{"/api/users/1", "global api group use sub route handler /route /sub /use /group /api /global"},
+		{"/health", "global handler /global"},
+	} {
+		trace = nil
+
+		serveRequest(gs, MGet, tt.uri, "")
+
+		if got := strings.Join(trace, " "); got != tt.trace {
+			t.Errorf("%s:\n got %s\nwant %s", tt.uri, got, tt.trace)
+		}
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var trace []string
+
+	gs := New()
+
+	gs.Middleware("*", traceMiddleware(&trace, "global"))
+	gs.Middleware("*", func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			return ErrUnauthorized
+		}
+	})
+
+	gs.Get("/", func(c *Context) error {
+		trace = append(trace, "handler")
+		return nil
+	}, traceMiddleware(&trace, "route"))
+
+	res := serveRequest(gs, MGet, "/", "")
+
+	if res.StatusCode() != ErrUnauthorized.Code {
+		t.Errorf("status = %d", res.StatusCode())
+	}
+
+	if got := strings.Join(trace, " "); got != "global /global" {
+		t.Errorf("trace = %s", got)
+	}
+}
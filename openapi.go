@@ -0,0 +1,589 @@
+package gosvelt
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OpenAPIInfo is the info object of the api documentation
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// serve an OpenAPI 3.1 document built from the registered routes
+// at path/openapi.json and an offline documentation page at path,
+// hidden routes (svelte pages, static files, sse streams, ...) are excluded
+//
+//	like this:
+//	app.OpenAPI("/docs", gosvelt.OpenAPIInfo{Title: "my api", Version: "1.0.0"})
+//
+// NOTE: the document is built on the first request, so every
+// route registered before the server start is documented
+func (gs *GoSvelt) OpenAPI(path string, info OpenAPIInfo) {
+	specPath := joinPath(path, "openapi.json")
+
+	var (
+		once    sync.Once
+		spec    []byte
+		specErr error
+	)
+
+	gs.add(MGet, specPath, func(c *Context) error {
+		once.Do(func() {
+			spec, specErr = json.Marshal(gs.openAPIDocument(info))
+		})
+
+		if specErr != nil {
+			return specErr
+		}
+
+		c.SetCType(MAppJsonUTF8)
+		c.Write(spec)
+
+		return nil
+	}, nil).Hide()
+
+	// a js string, json escape <, > and & so the script can't be closed
+	specUrl, _ := json.Marshal(specPath)
+
+	gs.add(MGet, path, func(c *Context) error {
+		c.SetCType(MTextHtmlUTF8)
+		c.Write([]byte(strings.NewReplacer(
+			"&{title}", html.EscapeString(info.Title),
+			"&{spec}", string(specUrl),
+		).Replace(openAPIPage)))
+
+		return nil
+	}, nil).Hide()
+}
+
+// build the OpenAPI 3.1 document
+func (gs *GoSvelt) openAPIDocument(info OpenAPIInfo) Map {
+	b := &openAPIBuilder{
+		names:   newTypeNames(),
+		schemas: make(Map),
+	}
+
+	if info.Title == "" {
+		info.Title = "GoSvelt API"
+	}
+	if info.Version == "" {
+		info.Version = "0.0.0"
+	}
+
+	errorSchema := b.schema(reflect.TypeOf(HTTPError{}))
+
+	paths := make(Map)
+	ids := operationIDs(gs.routes)
+
+	for _, route := range gs.routes {
+		if route.hidden {
+			continue
+		}
+
+		path := openAPIPath(route.Path)
+
+		item, ok := paths[path].(Map)
+		if !ok {
+			item = make(Map)
+			paths[path] = item
+		}
+
+		item[strings.ToLower(route.Method)] = b.operation(route, ids[route], errorSchema)
+	}
+
+	return Map{
+		"openapi": "3.1.0",
+		"info":    info,
+		"paths":   paths,
+		"components": Map{
+			"schemas": b.schemas,
+		},
+	}
+}
+
+type openAPIBuilder struct {
+	names   *typeNames
+	schemas Map
+}
+
+func (b *openAPIBuilder) operation(route *Route, id string, errorSchema Map) Map {
+	op := Map{
+		"operationId": id,
+		"responses": Map{
+			"default": Map{
+				"description": "Error",
+				"content": Map{
+					MAppJSON: Map{"schema": errorSchema},
+				},
+			},
+		},
+	}
+
+	if route.summary != "" {
+		op["summary"] = route.summary
+	}
+	if route.description != "" {
+		op["description"] = route.description
+	}
+	if len(route.tags) > 0 {
+		op["tags"] = route.tags
+	}
+
+	// parameters, path ones always exist
+	params := make([]Map, 0)
+	declared := make(map[string]bool)
+
+	var bodyFields []schemaField
+
+	if route.request != nil {
+		if t, _ := schemaType(route.request); t.Kind() == reflect.Struct {
+			for _, sf := range schemaFields(t) {
+				if sf.location == "body" {
+					bodyFields = append(bodyFields, sf)
+					continue
+				}
+
+				param := Map{
+					"name":   sf.key,
+					"in":     sf.location,
+					"schema": b.fieldSchema(sf),
+				}
+				if sf.location == "path" || sf.required() {
+					param["required"] = true
+				}
+
+				params = append(params, param)
+				declared[sf.location+":"+sf.key] = true
+			}
+
+		} else if hasBody(route.Method) {
+			op["requestBody"] = Map{
+				"required": true,
+				"content":  Map{MAppJSON: Map{"schema": b.schema(route.request)}},
+			}
+		}
+	}
+
+	for _, name := range pathParams(route.Path) {
+		if !declared["path:"+name] {
+			params = append(params, Map{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   Map{"type": "string"},
+			})
+		}
+	}
+
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if len(bodyFields) > 0 && hasBody(route.Method) {
+		op["requestBody"] = Map{
+			"required": true,
+			"content": Map{
+				MAppJSON: Map{"schema": b.objectSchema(bodyFields)},
+			},
+		}
+	}
+
+	status := route.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := Map{"description": http.StatusText(status)}
+	if route.response != nil && bodyAllowed(status) {
+		success["content"] = Map{
+			MAppJSON: Map{"schema": b.schema(route.response)},
+		}
+	}
+
+	op["responses"].(Map)[strconv.Itoa(status)] = success
+
+	return op
+}
+
+// json schema of a go type, named structs are
+// added to the components and referenced
+func (b *openAPIBuilder) schema(t reflect.Type) Map {
+	t, isText := schemaType(t)
+
+	switch {
+	case t == timeType:
+		return Map{"type": "string", "format": "date-time"}
+	case isText:
+		return Map{"type": "string"}
+	case t == rawMessageType:
+		return Map{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Map{"type": "boolean"}
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return Map{"type": "integer", "format": "int32"}
+
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return Map{"type": "integer", "format": "int64"}
+
+	case reflect.Float32:
+		return Map{"type": "number", "format": "float"}
+
+	case reflect.Float64:
+		return Map{"type": "number", "format": "double"}
+
+	case reflect.String:
+		return Map{"type": "string"}
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Map{"type": "string", "format": "byte"}
+		}
+
+		return Map{"type": "array", "items": b.schema(t.Elem())}
+
+	case reflect.Map:
+		return Map{"type": "object", "additionalProperties": b.schema(t.Elem())}
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.objectSchema(schemaFields(t))
+		}
+
+		name, isNew := b.names.name(t)
+		if isNew {
+			b.schemas[name] = Map{} // allow recursive types
+			b.schemas[name] = b.objectSchema(schemaFields(t))
+		}
+
+		return Map{"$ref": "#/components/schemas/" + name}
+	}
+
+	return Map{} // any
+}
+
+func (b *openAPIBuilder) objectSchema(fields []schemaField) Map {
+	properties := make(Map)
+	required := make([]string, 0)
+
+	for _, sf := range fields {
+		properties[sf.name] = nullable(sf, b.fieldSchema(sf))
+
+		if sf.required() {
+			required = append(required, sf.name)
+		}
+	}
+
+	schema := Map{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// a nil pointer field is encoded as null, unless it's omitted
+// (same as its typescript type)
+func nullable(sf schemaField, schema Map) Map {
+	if sf.field.Type.Kind() != reflect.Pointer || sf.omitempty || len(schema) == 0 {
+		return schema
+	}
+
+	typ, ok := schema["type"].(string)
+	if !ok {
+		return Map{"anyOf": []Map{schema, {"type": "null"}}}
+	}
+
+	schema["type"] = []string{typ, "null"}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		schema["enum"] = append(enum, nil)
+	}
+
+	return schema
+}
+
+// schema of a field with its validate rules as constraints
+func (b *openAPIBuilder) fieldSchema(sf schemaField) Map {
+	schema := b.schema(sf.field.Type)
+	if _, isRef := schema["$ref"]; isRef {
+		return schema
+	}
+
+	t, _ := schemaType(sf.field.Type)
+
+	for rule, param := range sf.rules() {
+		switch rule {
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+
+			var keys []string
+			switch t.Kind() {
+			case reflect.String:
+				keys = []string{"minLength", "maxLength"}
+			case reflect.Slice, reflect.Array:
+				keys = []string{"minItems", "maxItems"}
+			case reflect.Map:
+				keys = []string{"minProperties", "maxProperties"}
+			default:
+				keys = []string{"minimum", "maximum"}
+			}
+
+			switch rule {
+			case "min":
+				schema[keys[0]] = n
+			case "max":
+				schema[keys[1]] = n
+			case "len":
+				schema[keys[0]], schema[keys[1]] = n, n
+			}
+
+		case "regex":
+			schema["pattern"] = param
+
+		case "email":
+			schema["format"] = "email"
+
+		case "oneof":
+			var enum []interface{}
+			for _, option := range strings.Fields(param) {
+				if t.Kind() != reflect.String {
+					if n, err := strconv.ParseFloat(option, 64); err == nil {
+						enum = append(enum, n)
+						continue
+					}
+				}
+				enum = append(enum, option)
+			}
+			schema["enum"] = enum
+		}
+	}
+
+	return schema
+}
+
+// convert a router path to an openapi path
+//
+//	like this:
+//	openAPIPath("/users/:id/*file") // "/users/{id}/{file}"
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// names of the params of a router path
+func pathParams(path string) []string {
+	var params []string
+
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+		}
+	}
+
+	return params
+}
+
+// a camel case identifier for a route
+//
+//	like this:
+//	operationID("GET", "/users/:id") // "getUsersId"
+func operationID(method, path string) string {
+	var b strings.Builder
+
+	b.WriteString(strings.ToLower(method))
+
+	upper := true
+	for _, r := range path {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			if upper && r >= 'a' && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			b.WriteRune(r)
+			upper = false
+
+		default:
+			upper = true
+		}
+	}
+
+	return b.String()
+}
+
+// the operation id of every documented route, a number is
+// appended to the ids already taken by a previous route
+//
+//	like this:
+//	GET /users/:id // "getUsersId"
+//	GET /users/id  // "getUsersId2"
+func operationIDs(routes []*Route) map[*Route]string {
+	ids := make(map[*Route]string)
+	taken := make(map[string]bool)
+
+	for _, route := range routes {
+		if route.hidden {
+			continue
+		}
+
+		base := operationID(route.Method, route.Path)
+
+		id := base
+		for n := 2; taken[id]; n++ {
+			id = base + strconv.Itoa(n)
+		}
+
+		taken[id] = true
+		ids[route] = id
+	}
+
+	return ids
+}
+
+// true if requests of this method carry a body
+func hasBody(method string) bool {
+	switch method {
+	case MGet, MDelete, http.MethodHead, MOptions, MConnect:
+		return false
+	}
+
+	return true
+}
+
+// true if responses with this status carry a body
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= 200
+}
+
+// offline documentation page, it render the openapi
+// document without any external resource
+const openAPIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width,initial-scale=1">
+<title>&{title}</title>
+<style>
+body{margin:0;font:14px/1.5 system-ui,sans-serif;background:#fafafa;color:#222}
+header{background:#ff3e00;color:#fff;padding:16px 24px}
+header h1{margin:0;font-size:20px}
+main{max-width:960px;margin:0 auto;padding:16px}
+h2{margin:24px 0 8px;font-size:16px;text-transform:capitalize}
+details{background:#fff;border:1px solid #ddd;border-radius:4px;margin:6px 0}
+summary{cursor:pointer;padding:8px 12px;display:flex;gap:12px;align-items:center}
+.m{font:bold 12px monospace;color:#fff;border-radius:3px;padding:2px 6px;min-width:56px;text-align:center}
+.get{background:#2b8a3e}.post{background:#1864ab}.put{background:#e67700}.delete{background:#c92a2a}.options,.connect,.patch,.head{background:#5f3dc4}
+.p{font-family:monospace}.s{color:#666}
+.body{padding:0 12px 12px;border-top:1px solid #eee}
+table{border-collapse:collapse;width:100%}td,th{text-align:left;padding:4px 8px;border-bottom:1px solid #eee;vertical-align:top}
+pre{background:#272822;color:#f8f8f2;padding:8px;border-radius:4px;overflow:auto;font-size:12px}
+</style>
+</head>
+<body>
+<header><h1>&{title}</h1><div id="desc"></div></header>
+<main id="app">loading...</main>
+<script>
+(async function () {
+	const app = document.getElementById('app');
+	let spec;
+	try {
+		spec = await (await fetch(&{spec})).json();
+	} catch (e) {
+		app.textContent = 'cannot load the api document: ' + e;
+		return;
+	}
+
+	const esc = (s) => String(s).replace(/[&<>"']/g, (c) => '&#' + c.charCodeAt(0) + ';');
+	const schemas = (spec.components && spec.components.schemas) || {};
+
+	// expand a schema to an example like tree
+	function expand(schema, seen) {
+		if (!schema) return 'any';
+		if (schema.$ref) {
+			const name = schema.$ref.split('/').pop();
+			if (seen.includes(name)) return name;
+			return expand(schemas[name], seen.concat(name));
+		}
+		if (schema.type === 'array') return [expand(schema.items, seen)];
+		if (schema.type === 'object' && schema.properties) {
+			const out = {};
+			const req = schema.required || [];
+			for (const k in schema.properties) out[k + (req.includes(k) ? '' : '?')] = expand(schema.properties[k], seen);
+			return out;
+		}
+		if (schema.type === 'object') return { '[key]': expand(schema.additionalProperties, seen) };
+		let t = schema.type || 'any';
+		if (schema.format) t += ' (' + schema.format + ')';
+		if (schema.enum) t = schema.enum.join(' | ');
+		return t;
+	}
+	const show = (schema) => '<pre>' + esc(JSON.stringify(expand(schema, []), null, 2)) + '</pre>';
+
+	document.getElementById('desc').textContent = (spec.info.description || '') + ' v' + spec.info.version;
+
+	const groups = {};
+	for (const path in spec.paths) {
+		for (const method in spec.paths[path]) {
+			const op = spec.paths[path][method];
+			for (const tag of op.tags || ['default']) (groups[tag] = groups[tag] || []).push({ path, method, op });
+		}
+	}
+
+	let html = '';
+	for (const tag of Object.keys(groups).sort()) {
+		html += '<h2>' + esc(tag) + '</h2>';
+		for (const { path, method, op } of groups[tag]) {
+			html += '<details><summary><span class="m ' + method + '">' + method.toUpperCase() + '</span>'
+				+ '<span class="p">' + esc(path) + '</span><span class="s">' + esc(op.summary || '') + '</span></summary><div class="body">';
+			if (op.description) html += '<p>' + esc(op.description) + '</p>';
+			if (op.parameters) {
+				html += '<h4>Parameters</h4><table><tr><th>name</th><th>in</th><th>type</th><th>required</th></tr>';
+				for (const p of op.parameters) html += '<tr><td>' + esc(p.name) + '</td><td>' + esc(p.in) + '</td><td>'
+					+ esc(JSON.stringify(expand(p.schema, []))) + '</td><td>' + (p.required ? 'yes' : 'no') + '</td></tr>';
+				html += '</table>';
+			}
+			if (op.requestBody) {
+				for (const ct in op.requestBody.content) html += '<h4>Request body (' + esc(ct) + ')</h4>' + show(op.requestBody.content[ct].schema);
+			}
+			for (const code in op.responses) {
+				const res = op.responses[code];
+				html += '<h4>Response ' + esc(code) + ' - ' + esc(res.description) + '</h4>';
+				for (const ct in res.content || {}) html += show(res.content[ct].schema);
+			}
+			html += '</div></details>';
+		}
+	}
+
+	app.innerHTML = html || 'no documented route';
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,215 @@
+package gosvelt
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type openAPIUser struct {
+	ID      int          `json:"id"`
+	Name    string       `json:"name" validate:"required,min=2,max=32"`
+	Role    string       `json:"role" validate:"oneof=user admin"`
+	Email   *string      `json:"email"`
+	Nick    *string      `json:"nick,omitempty"`
+	Friend  *openAPIUser `json:"friend"`
+	Created time.Time    `json:"created"`
+	Avatar  []byte       `json:"avatar"`
+}
+
+type openAPIUpdateUser struct {
+	ID    int    `param:"id"`
+	Page  int    `query:"page" validate:"min=1"`
+	Token string `header:"X-Token" validate:"required"`
+	Name  string `json:"name"`
+}
+
+// the parts of an openapi document checked by the tests,
+// the schemas are kept raw and compared as json
+type openAPISpec struct {
+	OpenAPI string      `json:"openapi"`
+	Info    OpenAPIInfo `json:"info"`
+	Paths   map[string]map[string]struct {
+		OperationID string                    `json:"operationId"`
+		Summary     string                    `json:"summary"`
+		Tags        []string                  `json:"tags"`
+		Parameters  []json.RawMessage         `json:"parameters"`
+		RequestBody *openAPIContent           `json:"requestBody"`
+		Responses   map[string]openAPIContent `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIContent struct {
+	Description string `json:"description"`
+	Content     map[string]struct {
+		Schema json.RawMessage `json:"schema"`
+	} `json:"content"`
+}
+
+// get the document served by the app
+func fetchOpenAPI(t *testing.T, gs *GoSvelt) openAPISpec {
+	t.Helper()
+
+	gs.OpenAPI("/docs", OpenAPIInfo{Title: "test"})
+
+	res := serveRequest(gs, MGet, "/docs/openapi.json", "")
+	if res.StatusCode() != http.StatusOK {
+		t.Fatalf("status = %d", res.StatusCode())
+	}
+
+	var spec openAPISpec
+	if err := json.Unmarshal(res.Body(), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	return spec
+}
+
+func expectJSON(t *testing.T, what string, got json.RawMessage, want string) {
+	t.Helper()
+
+	if string(got) != want {
+		t.Errorf("%s:\n got %s\nwant %s", what, got, want)
+	}
+}
+
+func TestOpenAPIOperations(t *testing.T) {
+	gs := New()
+
+	gs.Get("/users/:id", func(c *Context) error { return nil }).
+		Summary("get a user").
+		Tags("users").
+		Response(openAPIUser{})
+	gs.Put("/users/:id", func(c *Context) error { return nil }).
+		Request(openAPIUpdateUser{})
+	gs.Post("/users", func(c *Context) error { return nil }).
+		Request(openAPIUser{}).
+		Response(&openAPIUser{})
+	gs.Get("/files/*path", func(c *Context) error { return nil })
+	gs.Get("/hidden", func(c *Context) error { return nil }).Hide()
+	gs.Sse("/events", nil, nil, func() {})
+
+	spec := fetchOpenAPI(t, gs)
+
+	if spec.OpenAPI != "3.1.0" || spec.Info.Version != "0.0.0" {
+		t.Errorf("openapi %s, version %s", spec.OpenAPI, spec.Info.Version)
+	}
+
+	for _, path := range []string{"/hidden", "/events", "/docs", "/docs/openapi.json"} {
+		if _, ok := spec.Paths[path]; ok {
+			t.Errorf("%s is documented", path)
+		}
+	}
+
+	get := spec.Paths["/users/{id}"]["get"]
+	if get.OperationID != "getUsersId" || get.Summary != "get a user" || strings.Join(get.Tags, ",") != "users" {
+		t.Errorf("get operation: %+v", get)
+	}
+	expectJSON(t, "get response", get.Responses["200"].Content[MAppJSON].Schema, `{"$ref":"#/components/schemas/openAPIUser"}`)
+
+	put := spec.Paths["/users/{id}"]["put"]
+	if len(put.Parameters) != 3 {
+		t.Fatalf("put parameters: %s", put.Parameters)
+	}
+	expectJSON(t, "path param", put.Parameters[0], `{"in":"path","name":"id","required":true,"schema":{"format":"int64","type":"integer"}}`)
+	expectJSON(t, "query param", put.Parameters[1], `{"in":"query","name":"page","schema":{"format":"int64","minimum":1,"type":"integer"}}`)
+	expectJSON(t, "header param", put.Parameters[2], `{"in":"header","name":"X-Token","required":true,"schema":{"type":"string"}}`)
+	expectJSON(t, "put body", put.RequestBody.Content[MAppJSON].Schema, `{"properties":{"name":{"type":"string"}},"type":"object"}`)
+
+	post := spec.Paths["/users"]["post"]
+	expectJSON(t, "error response", post.Responses["default"].Content[MAppJSON].Schema, `{"$ref":"#/components/schemas/HTTPError"}`)
+
+	files := spec.Paths["/files/{path}"]["get"]
+	if len(files.Parameters) != 1 {
+		t.Fatalf("files parameters: %s", files.Parameters)
+	}
+	expectJSON(t, "undeclared path param", files.Parameters[0], `{"in":"path","name":"path","required":true,"schema":{"type":"string"}}`)
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	gs := New()
+	gs.Get("/users", func(c *Context) error { return nil }).Response([]openAPIUser{})
+
+	user := fetchOpenAPI(t, gs).Components.Schemas["openAPIUser"]
+
+	if strings.Join(user.Required, ",") != "name" {
+		t.Errorf("required = %v", user.Required)
+	}
+
+	for name, want := range map[string]string{
+		"name":    `{"maxLength":32,"minLength":2,"type":"string"}`,
+		"role":    `{"enum":["user","admin"],"type":"string"}`,
+		"email":   `{"type":["string","null"]}`,
+		"nick":    `{"type":"string"}`,
+		"friend":  `{"anyOf":[{"$ref":"#/components/schemas/openAPIUser"},{"type":"null"}]}`,
+		"created": `{"format":"date-time","type":"string"}`,
+		"avatar":  `{"format":"byte","type":"string"}`,
+	} {
+		expectJSON(t, name, user.Properties[name], want)
+	}
+}
+
+func TestOpenAPIStatus(t *testing.T) {
+	gs := New()
+
+	gs.Delete("/users/:id", func(c *Context) error {
+		c.SetStatusCode(http.StatusNoContent)
+		return nil
+	}).
+		Response(openAPIUser{}).
+		Status(http.StatusNoContent)
+	gs.Post("/users", func(c *Context) error { return nil }).
+		Response(openAPIUser{}).
+		Status(http.StatusCreated)
+
+	spec := fetchOpenAPI(t, gs)
+
+	del := spec.Paths["/users/{id}"]["delete"].Responses
+	if _, ok := del["200"]; ok {
+		t.Error("a 204 route is documented as 200")
+	}
+	if res, ok := del["204"]; !ok || res.Content != nil || res.Description != "No Content" {
+		t.Errorf("204 response: %+v", res)
+	}
+
+	created := spec.Paths["/users"]["post"].Responses["201"]
+	expectJSON(t, "201 response", created.Content[MAppJSON].Schema, `{"$ref":"#/components/schemas/openAPIUser"}`)
+}
+
+func TestOpenAPIPage(t *testing.T) {
+	gs := New()
+	gs.OpenAPI("/docs</script>", OpenAPIInfo{Title: `<b>"api"</b>`})
+
+	body := string(serveRequest(gs, MGet, "/docs</script>", "").Body())
+
+	// the title and the spec url can't break the page
+	if !strings.Contains(body, `<title>&lt;b&gt;&#34;api&#34;&lt;/b&gt;</title>`) {
+		t.Error("the title is not escaped")
+	}
+
+	if !strings.Contains(body, `fetch("/docs\u003c/script\u003e/openapi.json")`) {
+		t.Error("the spec url is not escaped")
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	if got := openAPIPath("/users/:id/files/*file"); got != "/users/{id}/files/{file}" {
+		t.Errorf("openAPIPath = %s", got)
+	}
+
+	if got := strings.Join(pathParams("/users/:id/files/*file"), ","); got != "id,file" {
+		t.Errorf("pathParams = %s", got)
+	}
+
+	if got := operationID(MGet, "/api/v1/user-settings"); got != "getApiV1UserSettings" {
+		t.Errorf("operationID = %s", got)
+	}
+}
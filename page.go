@@ -0,0 +1,210 @@
+package gosvelt
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// a compiled svelte page, it's rebuilt when its
+// files change in dev mode (see WithDev)
+type sveltePage struct {
+	path    string
+	file    string
+	options []SvelteOption
+	opts    *SvelteOptions
+	lock    sync.RWMutex
+	buildId string
+	folder  string // build folder
+	svelte  Map
+}
+
+// build the page and serve its bundles, the
+// map given to the handlers is then replaced
+func (gs *GoSvelt) buildPage(page *sveltePage, apiClient string) error {
+	// compile svelte file to compFile, with the
+	// typescript client of the routes
+	buildId, buildFolder, err := BuildSvelte(
+		page.file,
+		append([]SvelteOption{withApiClient(apiClient)}, page.options...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("svelte: cannot build %s (%w)", page.path, err)
+	}
+
+	jsBundleUrl, err := url.JoinPath(page.path, buildId, "bundle.js")
+	if err != nil {
+		return err
+	}
+
+	cssBundleUrl, err := url.JoinPath(page.path, buildId, "bundle.css")
+	if err != nil {
+		return err
+	}
+
+	baseUrl, err := url.JoinPath(page.path, buildId)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := readViteManifest(buildFolder)
+	if err != nil {
+		return err
+	}
+
+	// the bundles of the previous builds are still served
+	// for the pages opened before the rebuild, the chunks and
+	// the assets are next to them (they are imported relatively)
+	if err := gs.serveViteAssets(baseUrl, filepath.Join(buildFolder, "assets")); err != nil {
+		return err
+	}
+
+	gs.assets.set(jsBundleUrl, filepath.Join(buildFolder, "bundle.js"))
+	gs.assets.set(cssBundleUrl, filepath.Join(buildFolder, "bundle.css"))
+
+	var preloads []string
+	for _, file := range manifest.imports(manifest["index.html"]) {
+		preloads = append(preloads, path.Join(baseUrl, strings.TrimPrefix(file, "assets/")))
+	}
+
+	// this map gives the js and css path, and the
+	// modulepreload links of the chunks of the page
+	return gs.setPageBuild(page, buildId, buildFolder, Map{
+		"js":      jsBundleUrl,
+		"css":     cssBundleUrl,
+		"preload": modulePreloads(preloads),
+	})
+}
+
+// serve every file of a build assets folder under baseUrl
+func (gs *GoSvelt) serveViteAssets(baseUrl, folder string) error {
+	return filepath.WalkDir(folder, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(folder, file)
+		if err != nil {
+			return err
+		}
+
+		gs.assets.set(path.Join(baseUrl, filepath.ToSlash(rel)), file)
+
+		return nil
+	})
+}
+
+// replace the build of a page, folder has its server bundle (if any)
+func (gs *GoSvelt) setPageBuild(page *sveltePage, buildId, folder string, svelteMap Map) error {
+	// the page is rendered by the ssr workers in
+	// the "head" and "body" values of the map
+	if page.opts.ssr {
+		pool, err := gs.ssrPool()
+		if err != nil {
+			return err
+		}
+
+		bundle, err := filepath.Abs(filepath.Join(folder, "server.mjs"))
+		if err != nil {
+			return err
+		}
+
+		svelteMap["head"] = ssrPart{pool: pool, bundle: bundle, name: "head"}
+		svelteMap["body"] = ssrPart{pool: pool, bundle: bundle, name: "body"}
+	}
+
+	page.lock.Lock()
+	page.buildId = buildId
+	page.folder = folder
+	page.svelte = svelteMap
+	page.lock.Unlock()
+
+	return nil
+}
+
+// the svelte map of the current build
+func (p *sveltePage) svelteMap() Map {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.svelte
+}
+
+// the id of the current build
+func (p *sveltePage) currentBuildId() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.buildId
+}
+
+// the files served outside of the router (the svelte bundles),
+// they can be added while the server is running
+type assetTable struct {
+	lock  sync.RWMutex
+	files map[string]string // url path -> file
+}
+
+func (a *assetTable) set(path, file string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.files == nil {
+		a.files = make(map[string]string)
+	}
+
+	a.files[path] = file
+}
+
+func (a *assetTable) get(path string) (string, bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	file, ok := a.files[path]
+
+	return file, ok
+}
+
+// the mime types of the files emitted by vite, the
+// system ones may miss or be wrong (e.g. .js on windows)
+var assetTypes = map[string]string{
+	".js":    MAppJsUTF8,
+	".mjs":   MAppJsUTF8,
+	".css":   "text/css; " + CharsetUTF8,
+	".json":  MAppJsonUTF8,
+	".map":   MAppJsonUTF8,
+	".html":  MTextHtmlUTF8,
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".wasm":  "application/wasm",
+}
+
+// the mime type of an asset
+func assetType(file string) string {
+	ext := strings.ToLower(filepath.Ext(file))
+
+	if mimeType, ok := assetTypes[ext]; ok {
+		return mimeType
+	}
+
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+
+	return MOctStream
+}
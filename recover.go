@@ -0,0 +1,107 @@
+package gosvelt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+// RecoverConfig configure the Recover middleware
+type RecoverConfig struct {
+	StackSize   int  // max size of the captured stack (default 4KB)
+	ExposeStack bool // send the stack trace to the client, only use it in dev
+}
+
+const defaultStackSize = 4 << 10
+
+// Recover is a middleware that convert panics into internal server
+// errors which go through the error handler, the stack trace is logged
+//
+//	like this:
+//	app.Middleware("*", gosvelt.Recover())
+func Recover(config ...RecoverConfig) MiddlewareFunc {
+	var cfg RecoverConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = panicError(c, r, cfg)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// convert a middleware to a svelte middleware,
+// the svelte map is kept in the context
+//
+//	like this:
+//	app.SvelteMiddleware("*", gosvelt.ToSvelteMiddleware(gosvelt.Recover()))
+func ToSvelteMiddleware(mw MiddlewareFunc) SvelteMiddlewareFunc {
+	return func(next SvelteHandlerFunc) SvelteHandlerFunc {
+		return applySvelteMiddlewares(next, []MiddlewareFunc{mw})
+	}
+}
+
+// log a recovered panic and convert it to an http error
+func panicError(c *Context, r interface{}, cfg RecoverConfig) error {
+	stack := captureStack(cfg.StackSize)
+
+	c.gosvelt.errorLogger().LogAttrs(c.Ctx, slog.LevelError, "panic recovered",
+		slog.String("path", c.Path()),
+		slog.String("request_id", RequestIDFromContext(c.Ctx)),
+		slog.Any("error", r),
+		slog.String("stack", string(stack)),
+	)
+
+	var cause error
+	if err, ok := r.(error); ok {
+		cause = fmt.Errorf("panic: %w", err)
+
+	} else {
+		cause = fmt.Errorf("panic: %v", r)
+	}
+
+	he := ErrInternalServerError.WithInternal(cause)
+	if cfg.ExposeStack {
+		he = he.WithDetails(fmt.Sprintf("%v\n\n%s", r, stack))
+	}
+
+	return he
+}
+
+// run fn and log its panic instead of crashing the process,
+// used for goroutines that outlive the request (sse, ws)
+func safeRun(logger *slog.Logger, name string, fn func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.LogAttrs(context.Background(), slog.LevelError, "panic recovered",
+				slog.String("in", name),
+				slog.Any("error", r),
+				slog.String("stack", string(captureStack(0))),
+			)
+			panicked = true
+		}
+	}()
+
+	fn()
+
+	return false
+}
+
+func captureStack(size int) []byte {
+	if size <= 0 {
+		size = defaultStackSize
+	}
+
+	stack := make([]byte, size)
+
+	return stack[:runtime.Stack(stack, false)]
+}
@@ -0,0 +1,65 @@
+package gosvelt
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRecoverLogsPanic(t *testing.T) {
+	var buf bytes.Buffer
+
+	gs := New(WithRecover(), WithLogHandler(slog.NewJSONHandler(&buf, nil)))
+	gs.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+
+	res := serveRequest(gs, MGet, "/panic", "", "Accept", MAppJSON)
+	if res.StatusCode() != 500 {
+		t.Fatalf("status = %d", res.StatusCode())
+	}
+
+	// the stack is logged but not sent
+	if strings.Contains(string(res.Body()), "boom") {
+		t.Errorf("body = %s", res.Body())
+	}
+
+	var logged bool
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("log line %s: %v", line, err)
+		}
+
+		if record["msg"] != "panic recovered" {
+			continue
+		}
+
+		logged = true
+
+		if record["error"] != "boom" || record["path"] != "/panic" || record["request_id"] == "" {
+			t.Errorf("record = %v", record)
+		}
+
+		if stack, _ := record["stack"].(string); !strings.Contains(stack, "goroutine") {
+			t.Errorf("stack = %q", stack)
+		}
+	}
+
+	if !logged {
+		t.Errorf("the panic was not logged:\n%s", buf.String())
+	}
+}
+
+func TestRecoverWithoutLog(t *testing.T) {
+	gs := New(WithRecover())
+	gs.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+
+	if res := serveRequest(gs, MGet, "/panic", ""); res.StatusCode() != 500 {
+		t.Errorf("status = %d", res.StatusCode())
+	}
+}
@@ -0,0 +1,83 @@
+package gosvelt
+
+import "reflect"
+
+// Route is a registered route, its metadata are used
+// to generate the api documentation (see GoSvelt.OpenAPI)
+//
+//	like this:
+//	app.Post("/users", gosvelt.Typed(createUser)).
+//		Summary("create an user").
+//		Tags("users").
+//		Request(CreateUser{}).
+//		Response(User{})
+type Route struct {
+	Method      string // route method
+	Path        string // route path as registered (e.g. /users/:id)
+	summary     string
+	description string
+	tags        []string
+	request     reflect.Type
+	response    reflect.Type
+	status      int // success status, 0 for 200
+	hidden      bool
+}
+
+func (gs *GoSvelt) addRoute(method, path string) *Route {
+	route := &Route{
+		Method: method,
+		Path:   path,
+	}
+
+	gs.routes = append(gs.routes, route)
+
+	return route
+}
+
+// set a short summary of the route
+func (r *Route) Summary(summary string) *Route {
+	r.summary = summary
+	return r
+}
+
+// set a long description of the route
+func (r *Route) Description(description string) *Route {
+	r.description = description
+	return r
+}
+
+// add tags used to group the routes
+func (r *Route) Tags(tags ...string) *Route {
+	r.tags = append(r.tags, tags...)
+	return r
+}
+
+// set the request type, its `param`, `query` and `header` tagged
+// fields are parameters, the other ones are the body
+func (r *Route) Request(v interface{}) *Route {
+	r.request = reflect.TypeOf(v)
+	return r
+}
+
+// set the response type
+func (r *Route) Response(v interface{}) *Route {
+	r.response = reflect.TypeOf(v)
+	return r
+}
+
+// set the success status of the route (default 200), a 204
+// or 304 response is documented without content
+//
+//	like this:
+//	app.Delete("/users/:id", deleteUser).Status(http.StatusNoContent)
+func (r *Route) Status(code int) *Route {
+	r.status = code
+	return r
+}
+
+// exclude the route from the api documentation
+// (svelte pages, static files and sse streams are hidden by default)
+func (r *Route) Hide() *Route {
+	r.hidden = true
+	return r
+}
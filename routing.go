@@ -0,0 +1,239 @@
+package gosvelt
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// a svelte page found in a views folder
+type svelteDirPage struct {
+	path   string   // url path (e.g. /blog/:slug)
+	file   string   // relative to the folder (e.g. blog/[slug].svelte)
+	params []string // route params (e.g. slug)
+}
+
+var svelteDirParam = regexp.MustCompile(`^\[(\.\.\.)?([A-Za-z_][A-Za-z0-9_]*)\]$`)
+
+// register a svelte page for every .svelte file of root, the
+// file path is the url path, route params are given as props
+//
+//	like this:
+//	views/index.svelte          -> /
+//	views/about.svelte          -> /about
+//	views/blog/+page.svelte     -> /blog
+//	views/blog/[slug].svelte    -> /blog/:slug   (export let slug;)
+//	views/docs/[...path].svelte -> /docs/*path   (export let path;)
+//
+// files and folders starting with `_` (e.g. _components) and the
+// `+` files (e.g. +layout.svelte, see WithLayout) aren't pages,
+// root is the root folder of every page (see WithRoot), nothing is
+// registered if two pages conflict (e.g. blog/new.svelte and
+// blog/[slug].svelte)
+func (gs *GoSvelt) SvelteDir(root string, handlerFn SvelteHandlerFunc, options ...SvelteOption) error {
+	return gs.addSvelteDir("", root, handlerFn, nil, options...)
+}
+
+// same as GoSvelt.SvelteDir, the pages are under the group prefix
+func (g *Group) SvelteDir(root string, handlerFn SvelteHandlerFunc, options ...SvelteOption) error {
+	return g.gosvelt.addSvelteDir(g.prefix, root, handlerFn, g.scoped(), options...)
+}
+
+func (gs *GoSvelt) addSvelteDir(
+	prefix, root string,
+	handlerFn SvelteHandlerFunc,
+	mws []MiddlewareFunc,
+	options ...SvelteOption,
+) error {
+	pages, err := svelteDirPages(root)
+	if err != nil {
+		return err
+	}
+
+	if len(pages) == 0 {
+		return fmt.Errorf("gosvelt: no svelte page found in %s", root)
+	}
+
+	// nothing is registered if two pages conflict
+	if err := svelteDirConflicts(pages); err != nil {
+		return err
+	}
+
+	options = append(options, WithRoot(root))
+
+	for _, page := range pages {
+		gs.addSvelteDirPage(joinPath(prefix, page.path), page, handlerFn, mws, options)
+	}
+
+	return nil
+}
+
+// register a page, its route params are given as props
+func (gs *GoSvelt) addSvelteDirPage(
+	urlPath string,
+	page svelteDirPage,
+	handlerFn SvelteHandlerFunc,
+	mws []MiddlewareFunc,
+	options []SvelteOption,
+) {
+	h := handlerFn
+
+	if len(page.params) > 0 {
+		h = func(c *Context, svelte Map) error {
+			props := make(Map, len(page.params))
+			for _, name := range page.params {
+				value, _ := c.Param(name).(string)
+				props[name] = strings.TrimPrefix(value, "/") // catch-all params start with /
+			}
+
+			if err := c.SvelteProps(props); err != nil {
+				return err
+			}
+
+			return handlerFn(c, svelte)
+		}
+	}
+
+	gs.addSvelte(urlPath, page.file, h, mws, options...)
+}
+
+// the router can't have a param or a catch-all next to another
+// route in a same segment, two pages conflict if they are the same
+// route or if they first differ by a param or a catch-all (the
+// root page only conflicts with a catch-all)
+//
+//	like this:
+//	blog/[id].svelte   and blog/[slug].svelte     // conflict
+//	blog/new.svelte    and blog/[slug].svelte     // conflict
+//	blog/+page.svelte  and blog/index.svelte      // conflict
+//	index.svelte       and [...path].svelte       // conflict
+//	index.svelte       and [lang].svelte          // ok
+//	blog/[slug].svelte and docs/[...path].svelte  // ok
+func svelteDirConflicts(pages []svelteDirPage) error {
+	for i, a := range pages {
+		for _, b := range pages[:i] {
+			if svelteDirConflict(a.path, b.path) {
+				return fmt.Errorf("gosvelt: the pages %s and %s conflict (%s and %s)", b.file, a.file, b.path, a.path)
+			}
+		}
+	}
+
+	return nil
+}
+
+func svelteDirConflict(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+
+		// the end of the root path "/"
+		if as[i] == "" || bs[i] == "" {
+			return strings.HasPrefix(as[i], "*") || strings.HasPrefix(bs[i], "*")
+		}
+
+		return isRouteWildcard(as[i]) || isRouteWildcard(bs[i])
+	}
+
+	return false
+}
+
+func isRouteWildcard(segment string) bool {
+	return strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+}
+
+// find the pages of a views folder
+func svelteDirPages(root string) ([]svelteDirPage, error) {
+	var pages []svelteDirPage
+
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+
+		if entry.IsDir() {
+			if file != root && (strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(name) != ".svelte" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") ||
+			(strings.HasPrefix(name, "+") && name != "+page.svelte") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+
+		urlPath, params, err := svelteDirPath(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		pages = append(pages, svelteDirPage{
+			path:   urlPath,
+			file:   rel,
+			params: params,
+		})
+
+		return nil
+	})
+
+	return pages, err
+}
+
+// convert a page file to a router path
+//
+//	like this:
+//	svelteDirPath("blog/[slug].svelte") // "/blog/:slug", ["slug"]
+func svelteDirPath(file string) (string, []string, error) {
+	file = strings.TrimSuffix(file, ".svelte")
+
+	segments := strings.Split(file, "/")
+	if last := segments[len(segments)-1]; last == "index" || last == "+page" {
+		segments = segments[:len(segments)-1]
+	}
+
+	var params []string
+
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "[") {
+			continue
+		}
+
+		match := svelteDirParam.FindStringSubmatch(segment)
+		if match == nil {
+			return "", nil, fmt.Errorf("gosvelt: invalid route param %s in %s.svelte", segment, file)
+		}
+
+		if match[1] != "" { // catch-all
+			if i != len(segments)-1 {
+				return "", nil, fmt.Errorf("gosvelt: the catch-all param %s must be the last segment of %s.svelte", segment, file)
+			}
+
+			segments[i] = "*" + match[2]
+
+		} else {
+			segments[i] = ":" + match[2]
+		}
+
+		params = append(params, match[2])
+	}
+
+	return path.Join("/", strings.Join(segments, "/")), params, nil
+}
@@ -0,0 +1,89 @@
+package gosvelt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/buaazp/fasthttprouter"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSvelteDirPath(t *testing.T) {
+	for _, tt := range []struct {
+		file, path, params, err string
+	}{
+		{file: "index.svelte", path: "/"},
+		{file: "+page.svelte", path: "/"},
+		{file: "about.svelte", path: "/about"},
+		{file: "blog/index.svelte", path: "/blog"},
+		{file: "blog/+page.svelte", path: "/blog"},
+		{file: "blog/[slug].svelte", path: "/blog/:slug", params: "slug"},
+		{file: "[lang]/blog/[slug].svelte", path: "/:lang/blog/:slug", params: "lang,slug"},
+		{file: "docs/[...path].svelte", path: "/docs/*path", params: "path"},
+		{file: "[...path]/edit.svelte", err: "must be the last segment"},
+		{file: "blog/[1slug].svelte", err: "invalid route param"},
+		{file: "blog/[slug.svelte", err: "invalid route param"},
+	} {
+		path, params, err := svelteDirPath(tt.file)
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.file, err, tt.err)
+			}
+
+			continue
+		}
+
+		if err != nil || path != tt.path || strings.Join(params, ",") != tt.params {
+			t.Errorf("%s: %q %v %v, want %q [%s]", tt.file, path, params, err, tt.path, tt.params)
+		}
+	}
+}
+
+// true if the router panics when both paths are registered
+func routerConflict(a, b string) (conflict bool) {
+	defer func() {
+		conflict = recover() != nil
+	}()
+
+	router := fasthttprouter.New()
+	router.GET(a, func(*fasthttp.RequestCtx) {})
+	router.GET(b, func(*fasthttp.RequestCtx) {})
+
+	return false
+}
+
+func TestSvelteDirConflict(t *testing.T) {
+	for _, tt := range []struct {
+		a, b     string
+		conflict bool
+	}{
+		{"/", "/:lang", false},
+		{"/", "/about", false},
+		{"/", "/*path", true},
+		{"/about", "/:lang", true},
+		{"/blog", "/blog/:slug", false},
+		{"/blog", "/blog/*path", false},
+		{"/blog/new", "/blog/:slug", true},
+		{"/blog/:id", "/blog/:slug", true},
+		{"/blog/:slug", "/blog/*path", true},
+		{"/blog/:slug", "/blog/:slug/edit", false},
+		{"/blog/new", "/blog/:slug/edit", true},
+		{"/blog/:slug", "/blogs", false},
+		{"/blog/:slug", "/docs/*path", false},
+		{"/:lang", "/:lang/about", false},
+		{"/:a/x", "/:b/y", true},
+		{"/blog", "/blog", true},
+	} {
+		for _, pair := range [][2]string{{tt.a, tt.b}, {tt.b, tt.a}} {
+			if got := svelteDirConflict(pair[0], pair[1]); got != tt.conflict {
+				t.Errorf("svelteDirConflict(%s, %s) = %v, want %v", pair[0], pair[1], got, tt.conflict)
+			}
+
+			// only the conflicts of the router are reported
+			if router := routerConflict(pair[0], pair[1]); router != tt.conflict {
+				t.Errorf("the router conflict of %s and %s is %v", pair[0], pair[1], router)
+			}
+		}
+	}
+}
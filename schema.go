@@ -0,0 +1,189 @@
+package gosvelt
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a struct field as seen by the json encoder
+type schemaField struct {
+	name      string // json name
+	field     reflect.StructField
+	omitempty bool
+	location  string // body, path, query or header
+	key       string // parameter name for non body fields
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// list the fields of a struct type, embedded structs are flattened
+func schemaFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		jsonTag, hasJSON := field.Tag.Lookup("json")
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if field.Anonymous && !hasJSON && ft.Kind() == reflect.Struct {
+			fields = append(fields, schemaFields(ft)...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		sf := schemaField{
+			name:      name,
+			field:     field,
+			omitempty: strings.Contains(opts, "omitempty"),
+			location:  "body",
+		}
+
+		// bound from the request but not from its body
+		// (see Context.Bind)
+		if !hasJSON {
+			for _, loc := range [...][2]string{{"param", "path"}, {"query", "query"}, {"header", "header"}} {
+				if key, ok := field.Tag.Lookup(loc[0]); ok {
+					key, _, _ = strings.Cut(key, ",")
+					if key == "" {
+						key = field.Name
+					}
+
+					sf.location, sf.key = loc[1], key
+					break
+				}
+			}
+		}
+
+		fields = append(fields, sf)
+	}
+
+	return fields
+}
+
+// true if the field has the validate required rule
+func (sf schemaField) required() bool {
+	rules, _, _ := strings.Cut(sf.field.Tag.Get("validate"), ",dive")
+
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the validate rules of the field (before dive)
+func (sf schemaField) rules() map[string]string {
+	rules := make(map[string]string)
+
+	tag, _, _ := strings.Cut(sf.field.Tag.Get("validate"), ",dive")
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "" {
+			continue
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+		rules[name] = param
+	}
+
+	return rules
+}
+
+// deref pointers, true is returned for types encoded by
+// themselves as json strings (time, text marshalers, ...)
+func schemaType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return t, true
+	}
+
+	if t.Kind() != reflect.String && (t.Implements(textMarshalType) || reflect.PointerTo(t).Implements(textMarshalType)) &&
+		!(t.Implements(jsonMarshalType) || reflect.PointerTo(t).Implements(jsonMarshalType)) {
+		return t, true
+	}
+
+	return t, false
+}
+
+// a name usable in schemas and typescript for a named type
+//
+//	like this:
+//	schemaName(reflect.TypeOf(Page[User]{})) // "Page_main_User"
+func schemaName(t reflect.Type) string {
+	var b strings.Builder
+
+	for _, r := range t.Name() {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == '[', r == ',', r == '.', r == ' ':
+			b.WriteByte('_')
+		}
+	}
+
+	return strings.Trim(b.String(), "_")
+}
+
+// unique names for named struct types, two types with the
+// same name in different packages get a numeric suffix
+type typeNames struct {
+	names map[reflect.Type]string
+	taken map[string]bool
+}
+
+func newTypeNames() *typeNames {
+	return &typeNames{
+		names: make(map[reflect.Type]string),
+		taken: make(map[string]bool),
+	}
+}
+
+func (tn *typeNames) name(t reflect.Type) (name string, isNew bool) {
+	if name, ok := tn.names[t]; ok {
+		return name, false
+	}
+
+	base := schemaName(t)
+	name = base
+
+	for i := 2; tn.taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	tn.names[t] = name
+	tn.taken[name] = true
+
+	return name, true
+}
@@ -0,0 +1,73 @@
+package gosvelt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ServerConfig tune the underlying fasthttp server,
+// zero values keep the fasthttp defaults
+type ServerConfig struct {
+	Name               string        // server header value
+	ReadTimeout        time.Duration // max time to read the full request
+	WriteTimeout       time.Duration // max time to write the full response
+	IdleTimeout        time.Duration // max keep-alive idle time (default ReadTimeout)
+	MaxRequestBodySize int           // max request body size in bytes (default 4MB)
+	Concurrency        int           // max concurrent connections (default 256 * 1024)
+	MaxConnsPerIP      int           // max concurrent connections per client ip
+	MaxRequestsPerConn int           // max requests served per connection
+	ReduceMemoryUsage  bool          // use less memory but more cpu
+	DisableKeepalive   bool          // close the connection after each response
+}
+
+// build the fasthttp server from the options
+func newServer(opts *Options) *fasthttp.Server {
+	cfg := opts.server
+
+	server := &fasthttp.Server{
+		Name:               cfg.Name,
+		ReadTimeout:        cfg.ReadTimeout,
+		WriteTimeout:       cfg.WriteTimeout,
+		IdleTimeout:        cfg.IdleTimeout,
+		MaxRequestBodySize: cfg.MaxRequestBodySize,
+		Concurrency:        cfg.Concurrency,
+		MaxConnsPerIP:      cfg.MaxConnsPerIP,
+		MaxRequestsPerConn: cfg.MaxRequestsPerConn,
+		ReduceMemoryUsage:  cfg.ReduceMemoryUsage,
+		DisableKeepalive:   cfg.DisableKeepalive,
+	}
+
+	for _, fn := range opts.serverFns {
+		fn(server)
+	}
+
+	return server
+}
+
+// check the final server configuration (WithServer included)
+// for invalid or conflicting values
+func validateServer(s *fasthttp.Server) error {
+	switch {
+	case s.ReadTimeout < 0, s.WriteTimeout < 0, s.IdleTimeout < 0:
+		return fmt.Errorf("gosvelt: server timeouts cannot be negative")
+
+	case s.MaxRequestBodySize < 0:
+		return fmt.Errorf("gosvelt: server max request body size cannot be negative")
+
+	case s.Concurrency < 0, s.MaxConnsPerIP < 0, s.MaxRequestsPerConn < 0:
+		return fmt.Errorf("gosvelt: server connection limits cannot be negative")
+
+	case s.Concurrency > 0 && s.MaxConnsPerIP > s.Concurrency:
+		return fmt.Errorf("gosvelt: server max conns per ip (%d) is greater than the concurrency (%d)", s.MaxConnsPerIP, s.Concurrency)
+
+	case s.DisableKeepalive && s.IdleTimeout > 0:
+		return fmt.Errorf("gosvelt: server idle timeout is useless with keep-alive disabled")
+
+	case s.DisableKeepalive && s.MaxRequestsPerConn > 0:
+		return fmt.Errorf("gosvelt: server max requests per conn is useless with keep-alive disabled")
+	}
+
+	return nil
+}
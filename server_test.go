@@ -0,0 +1,150 @@
+package gosvelt
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// get uri with a client dialing through dial
+func getWith(t *testing.T, dial fasthttp.DialFunc, uri string) string {
+	t.Helper()
+
+	client := &fasthttp.Client{Dial: dial}
+
+	code, body, err := client.GetTimeout(nil, uri, 5*time.Second)
+	if err != nil {
+		t.Fatalf("GET %s: %v", uri, err)
+	}
+
+	if code != fasthttp.StatusOK {
+		t.Fatalf("GET %s: status = %d", uri, code)
+	}
+
+	return string(body)
+}
+
+func TestServeListeners(t *testing.T) {
+	starts := 0
+
+	gs := New()
+	gs.OnStart(func() error {
+		starts++
+		return nil
+	})
+	gs.Get("/ping", String("pong"))
+
+	first := fasthttputil.NewInmemoryListener()
+	second := fasthttputil.NewInmemoryListener()
+
+	served := make(chan error, 2)
+	go func() { served <- gs.Serve(first) }()
+	go func() { served <- gs.Serve(second) }()
+
+	for _, ln := range []*fasthttputil.InmemoryListener{first, second} {
+		dial := func(string) (net.Conn, error) { return ln.Dial() }
+
+		if body := getWith(t, dial, "http://gosvelt/ping"); body != "pong" {
+			t.Errorf("body = %q", body)
+		}
+	}
+
+	if err := gs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-served; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	}
+
+	// the server is set up once for both listeners
+	if starts != 1 {
+		t.Errorf("start hooks called %d times", starts)
+	}
+}
+
+func TestServeStartError(t *testing.T) {
+	gs := New()
+	gs.OnStart(func() error {
+		return os.ErrPermission
+	})
+
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+
+	if err := gs.Serve(ln); err != os.ErrPermission {
+		t.Errorf("Serve = %v, want the start hook error", err)
+	}
+}
+
+func TestStartUnix(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "gosvelt.sock")
+
+	// a stale socket file is replaced
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets are not available: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	gs := New()
+	gs.Get("/ping", String("pong"))
+
+	served := make(chan error, 1)
+	go func() { served <- gs.StartUnix(sock, 0660) }()
+
+	dial := func(string) (net.Conn, error) { return net.Dial("unix", sock) }
+
+	var fi os.FileInfo
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if conn, err := dial(""); err == nil {
+			conn.Close()
+			fi, _ = os.Stat(sock)
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the unix socket is not served")
+		}
+	}
+
+	if body := getWith(t, dial, "http://gosvelt/ping"); body != "pong" {
+		t.Errorf("body = %q", body)
+	}
+
+	if fi == nil || fi.Mode().Perm() != 0660 {
+		t.Errorf("socket file mode = %v", fi)
+	}
+
+	if err := gs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("StartUnix: %v", err)
+	}
+}
+
+func TestListenUnixKeepsOtherFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(file, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ListenUnix(file, 0660); err == nil {
+		t.Fatal("a regular file must not be replaced by the socket")
+	}
+
+	if data, err := os.ReadFile(file); err != nil || string(data) != "keep" {
+		t.Errorf("the file was changed: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,471 @@
+package gosvelt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ssrAppId   = "gosvelt-app" // id of the element wrapping the rendered page
+	ssrTimeout = 10 * time.Second
+)
+
+var (
+	errSSRClosed = fmt.Errorf("svelte: ssr: the renderer is closed")
+	errSSRWorker = fmt.Errorf("svelte: ssr: the node worker exited")
+)
+
+// the node script of the ssr workers, it reads one json request per
+// line on stdin and writes the rendered page as a json line on stdout
+const ssrWorkerScript = `import { createInterface } from 'node:readline';
+import { pathToFileURL } from 'node:url';
+
+// stdout is used by the protocol
+console.log = console.info = console.debug = console.error;
+
+// the esm cache can't be freed, the worker asks to be replaced
+// once it loaded maxBundles bundles (e.g. rebuilt pages in dev)
+const maxBundles = 64;
+const bundles = new Map();
+let loaded = 0;
+
+function load(file) {
+	if (!bundles.has(file)) {
+		bundles.set(file, import(pathToFileURL(file).href));
+		loaded++;
+	}
+
+	return bundles.get(file);
+}
+
+function write(res) {
+	process.stdout.write(JSON.stringify(res) + '\n');
+}
+
+createInterface({ input: process.stdin, crlfDelay: Infinity }).on('line', async (line) => {
+	let req;
+	try {
+		req = JSON.parse(line);
+	} catch {
+		return;
+	}
+
+	try {
+		const { render } = await load(req.bundle);
+		const { html, head, css } = render(req.props || {});
+
+		write({ id: req.id, html, head, css: (css && css.code) || '', retire: loaded >= maxBundles });
+	} catch (err) {
+		bundles.delete(req.bundle);
+		write({ id: req.id, error: String((err && err.stack) || err), retire: loaded >= maxBundles });
+	}
+});
+`
+
+type ssrRequest struct {
+	ID     uint64          `json:"id"`
+	Bundle string          `json:"bundle"`
+	Props  json.RawMessage `json:"props,omitempty"`
+}
+
+type ssrResponse struct {
+	ID     uint64 `json:"id"`
+	Html   string `json:"html"`
+	Head   string `json:"head"`
+	Css    string `json:"css"`
+	Error  string `json:"error"`
+	Retire bool   `json:"retire"` // too many bundles loaded
+}
+
+// a pool of node processes rendering the server bundles,
+// workers are started on demand up to size
+type ssrPool struct {
+	script  string
+	idle    chan *ssrWorker
+	slots   chan struct{} // one by started worker, freed when it's stopped
+	done    chan struct{} // closed with the pool
+	lock    sync.Mutex
+	workers map[*ssrWorker]struct{}
+	closed  bool
+	nextId  atomic.Uint64
+}
+
+func newSSRPool(script string, size int) *ssrPool {
+	return &ssrPool{
+		script:  script,
+		idle:    make(chan *ssrWorker, size),
+		slots:   make(chan struct{}, size),
+		done:    make(chan struct{}),
+		workers: make(map[*ssrWorker]struct{}),
+	}
+}
+
+// get the ssr pool of the app, it's created with the first ssr page
+// and its workers are stopped on shutdown
+func (gs *GoSvelt) ssrPool() (*ssrPool, error) {
+	if gs.ssr != nil {
+		return gs.ssr, nil
+	}
+
+	if _, err := exec.LookPath("node"); err != nil {
+		return nil, fmt.Errorf("svelte: ssr: node is not available on your system, please install it")
+	}
+
+	if err := os.MkdirAll(svelteWorkdir, 0755); err != nil {
+		return nil, err
+	}
+
+	script, err := filepath.Abs(filepath.Join(svelteWorkdir, "ssr-worker.mjs"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(script, []byte(ssrWorkerScript), 0644); err != nil {
+		return nil, fmt.Errorf("svelte: ssr: cannot write the worker script in %s", script)
+	}
+
+	size := gs.config.ssrWorkers
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+
+	gs.ssr = newSSRPool(script, size)
+
+	gs.OnShutdown(gs.ssr.close)
+
+	return gs.ssr, nil
+}
+
+// render a server bundle with the given json props
+func (p *ssrPool) render(ctx context.Context, bundle string, props json.RawMessage) (ssrResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, ssrTimeout)
+	defer cancel()
+
+	w, err := p.get(ctx)
+	if err != nil {
+		return ssrResponse{}, err
+	}
+
+	res, err := w.render(ctx, ssrRequest{
+		ID:     p.nextId.Add(1),
+		Bundle: bundle,
+		Props:  props,
+	})
+
+	p.put(w)
+
+	return res, err
+}
+
+// take an idle worker, or start a new one if a slot is free,
+// a slot is freed when a broken worker is stopped
+func (p *ssrPool) get(ctx context.Context) (*ssrWorker, error) {
+	select {
+	case <-p.done:
+		return nil, errSSRClosed
+
+	case w := <-p.idle:
+		return w, nil
+
+	default:
+	}
+
+	select {
+	case w := <-p.idle:
+		return w, nil
+
+	case p.slots <- struct{}{}:
+		return p.start()
+
+	case <-p.done:
+		return nil, errSSRClosed
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// start a worker in a reserved slot
+func (p *ssrPool) start() (*ssrWorker, error) {
+	w, err := startSSRWorker(p.script)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		w.kill()
+		<-p.slots
+
+		return nil, errSSRClosed
+	}
+
+	p.workers[w] = struct{}{}
+
+	return w, nil
+}
+
+// give back a worker, it's stopped if it's broken (or retired)
+// or if the pool is closed, and its slot is freed
+func (p *ssrPool) put(w *ssrWorker) {
+	p.lock.Lock()
+
+	if w.broken || p.closed {
+		w.kill()
+		delete(p.workers, w)
+		p.lock.Unlock()
+
+		// a waiting get can start a new worker
+		<-p.slots
+
+		return
+	}
+
+	p.lock.Unlock()
+
+	p.idle <- w
+}
+
+// stop the workers, they exit when their stdin is closed
+// and are killed if it takes longer than ctx
+func (p *ssrPool) close(ctx context.Context) error {
+	p.lock.Lock()
+
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	close(p.done)
+
+	workers := make([]*ssrWorker, 0, len(p.workers))
+	for w := range p.workers {
+		workers = append(workers, w)
+		w.stdin.Close()
+	}
+
+	p.workers = make(map[*ssrWorker]struct{})
+	p.lock.Unlock()
+
+	for _, w := range workers {
+		select {
+		case <-w.exited:
+		case <-ctx.Done():
+			w.kill()
+		}
+	}
+
+	return nil
+}
+
+// a node process rendering one page at a time
+type ssrWorker struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan ssrResponse // closed when the process exit
+	exited    chan struct{}
+	broken    bool
+}
+
+func startSSRWorker(script string) (*ssrWorker, error) {
+	cmd := exec.Command("node", script)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("svelte: ssr: cannot start node worker (%s)", err)
+	}
+
+	w := &ssrWorker{
+		cmd:       cmd,
+		stdin:     stdin,
+		responses: make(chan ssrResponse, 1),
+		exited:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.exited)
+		defer cmd.Wait()
+		defer close(w.responses)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+		for scanner.Scan() {
+			var res ssrResponse
+			if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+				continue
+			}
+
+			w.responses <- res
+		}
+	}()
+
+	return w, nil
+}
+
+func (w *ssrWorker) render(ctx context.Context, req ssrRequest) (ssrResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return ssrResponse{}, err
+	}
+
+	if _, err := w.stdin.Write(append(data, '\n')); err != nil {
+		w.broken = true
+		return ssrResponse{}, errSSRWorker
+	}
+
+	select {
+	case res, ok := <-w.responses:
+		if !ok || res.ID != req.ID {
+			w.broken = true
+			return ssrResponse{}, errSSRWorker
+		}
+
+		// replaced by a new worker to free its bundles
+		if res.Retire {
+			w.broken = true
+		}
+
+		if res.Error != "" {
+			return ssrResponse{}, fmt.Errorf("svelte: ssr: %s", res.Error)
+		}
+
+		return res, nil
+
+	case <-ctx.Done():
+		// the response would be read by the next request
+		w.broken = true
+		return ssrResponse{}, ctx.Err()
+	}
+}
+
+func (w *ssrWorker) kill() {
+	w.stdin.Close()
+
+	if w.cmd.Process != nil {
+		w.cmd.Process.Kill()
+	}
+}
+
+// a server rendered part of a page, replaced in the
+// svelte map of each request by an ssrValue
+type ssrPart struct {
+	pool   *ssrPool
+	bundle string
+	name   string // head or body
+}
+
+// the server rendering of a page for one request,
+// it's done once for both the head and the body
+type ssrRender struct {
+	pool   *ssrPool
+	bundle string
+	c      *Context
+	done   bool
+	res    ssrResponse
+	err    error
+}
+
+func (r *ssrRender) render() error {
+	if r.done {
+		return r.err
+	}
+
+	r.done = true
+
+	var props json.RawMessage
+	if p, ok := r.c.svelte["props"].(svelteProps); ok && len(p) > 0 {
+		props, r.err = json.Marshal(map[string]json.RawMessage(p))
+		if r.err != nil {
+			return r.err
+		}
+	}
+
+	r.res, r.err = r.pool.render(r.c.Ctx, r.bundle, props)
+
+	if errors.Is(r.err, context.DeadlineExceeded) {
+		r.err = fmt.Errorf("svelte: ssr: rendering took more than %s", ssrTimeout)
+	}
+
+	return r.err
+}
+
+// a value of the svelte map rendered by the ssr workers
+type ssrValue struct {
+	render *ssrRender
+	name   string
+}
+
+// render the page, called by Context.Html before the
+// map values are formated so the errors are returned
+func (v ssrValue) prerender() error {
+	return v.render.render()
+}
+
+func (v ssrValue) String() string {
+	if err := v.render.render(); err != nil {
+		return ""
+	}
+
+	switch v.name {
+	case "head":
+		if v.render.res.Css != "" {
+			return v.render.res.Head + "<style>" + v.render.res.Css + "</style>"
+		}
+
+		return v.render.res.Head
+
+	case "body":
+		// the client bundle hydrate this element
+		return `<div id="` + ssrAppId + `">` + v.render.res.Html + `</div>`
+	}
+
+	return ""
+}
+
+// bind the ssr parts of a svelte map to a request
+func bindSSR(c *Context, svelte Map) {
+	var render *ssrRender
+
+	for key, value := range svelte {
+		part, ok := value.(ssrPart)
+		if !ok {
+			continue
+		}
+
+		if render == nil {
+			render = &ssrRender{
+				pool:   part.pool,
+				bundle: part.bundle,
+				c:      c,
+			}
+		}
+
+		svelte[key] = ssrValue{render: render, name: part.name}
+	}
+}
@@ -0,0 +1,78 @@
+package gosvelt
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a bundle taking some time to render, so a second request
+// waits for the only worker of the pool
+const slowSSRBundle = `export function render(props) {
+	const end = Date.now() + 300;
+	while (Date.now() < end) {}
+
+	return { html: '<p>' + props.name + '</p>', head: '', css: { code: '' } };
+}
+`
+
+func TestSSRPoolRetiredWorkerWakesWaiter(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not available")
+	}
+
+	dir := t.TempDir()
+
+	// every worker retires after its first bundle
+	script := strings.Replace(ssrWorkerScript, "const maxBundles = 64;", "const maxBundles = 1;", 1)
+	if script == ssrWorkerScript {
+		t.Fatal("maxBundles not found in the worker script")
+	}
+
+	scriptFile := filepath.Join(dir, "worker.mjs")
+	bundle := filepath.Join(dir, "page.mjs")
+
+	if err := os.WriteFile(scriptFile, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(bundle, []byte(slowSSRBundle), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := newSSRPool(scriptFile, 1)
+	defer pool.close(context.Background())
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := pool.render(context.Background(), bundle, []byte(`{"name":"a"}`))
+		first <- err
+	}()
+
+	// wait for the first request to hold the only slot
+	for len(pool.slots) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+
+	res, err := pool.render(ctx, bundle, []byte(`{"name":"b"}`))
+	if err != nil {
+		t.Fatalf("waiting request: %v (after %s)", err, time.Since(start))
+	}
+
+	if res.Html != "<p>b</p>" {
+		t.Errorf("html = %q", res.Html)
+	}
+
+	if err := <-first; err != nil {
+		t.Errorf("first request: %v", err)
+	}
+}
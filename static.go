@@ -0,0 +1,387 @@
+package gosvelt
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// rendered paths kept in memory by static page, the least
+// recently used ones are then reloaded from the disk cache
+const staticCacheSize = 1024
+
+// a svelte page rendered to html and cached on disk under its
+// build workdir, for the prerendered (SSG) and the revalidated
+// (ISR) pages (see WithPrerender and WithRevalidate)
+type staticPage struct {
+	pattern    string
+	page       *sveltePage
+	revalidate time.Duration // 0 if the page is only prerendered
+	render     fasthttp.RequestHandler
+	logger     *slog.Logger
+	lock       sync.Mutex
+	entries    map[string]*staticEntry // by request path
+	recent     *list.List              // request paths, most recently used first
+}
+
+// a rendered path of a static page
+type staticEntry struct {
+	params     map[string]interface{} // route params used to render it
+	body       []byte                 // nil until rendered
+	etag       string
+	modified   time.Time
+	generating chan struct{} // closed at the end of the rendering
+	element    *list.Element // in staticPage.recent
+}
+
+func (gs *GoSvelt) newStaticPage(pattern string, svelte *sveltePage, render fasthttp.RequestHandler, opts *SvelteOptions) *staticPage {
+	page := &staticPage{
+		pattern:    pattern,
+		page:       svelte,
+		revalidate: opts.revalidate,
+		render:     render,
+		logger:     gs.errorLogger(),
+		entries:    make(map[string]*staticEntry),
+		recent:     list.New(),
+	}
+
+	gs.staticPages = append(gs.staticPages, page)
+
+	// pages without params are rendered before the start,
+	// the other ones on their first request
+	if opts.prerender && len(pathParams(pattern)) == 0 {
+		gs.OnStart(func() error {
+			if _, rc := page.generate(pattern, nil); rc.Response.StatusCode() != http.StatusOK {
+				return fmt.Errorf("svelte: cannot prerender %s (status %d)", pattern, rc.Response.StatusCode())
+			}
+
+			return nil
+		})
+	}
+
+	return page
+}
+
+// regenerate a static page in the background (e.g. from a cms
+// webhook), an error is returned if no page is cached at path
+//
+//	like this:
+//	app.Post("/hooks/publish", func(c *gosvelt.Context) error {
+//		return app.Revalidate("/blog/" + string(c.Args().Peek("slug")))
+//	})
+func (gs *GoSvelt) Revalidate(path string) error {
+	found := false
+
+	for _, page := range gs.staticPages {
+		if page.invalidate(path) {
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("gosvelt: no static page cached at %s", path)
+	}
+
+	return nil
+}
+
+// serve the cached page, it's rendered on the first request
+// and regenerated in the background once stale
+func (p *staticPage) serve(ctx *fasthttp.RequestCtx) {
+	path := string(ctx.Path())
+
+	for {
+		p.lock.Lock()
+
+		entry := p.entries[path]
+
+		if entry != nil && entry.body != nil {
+			p.recent.MoveToFront(entry.element)
+
+			if p.stale(entry) && entry.generating == nil {
+				p.regenerate(path, entry)
+			}
+
+			body, etag, modified := entry.body, entry.etag, entry.modified
+			p.lock.Unlock()
+
+			p.write(ctx, body, etag, modified)
+
+			return
+		}
+
+		// wait for the request already rendering it
+		if entry != nil && entry.generating != nil {
+			generating := entry.generating
+			p.lock.Unlock()
+
+			<-generating
+
+			continue
+		}
+
+		if entry == nil {
+			entry = p.add(path)
+		}
+
+		params := routeParams(ctx)
+
+		entry.params = params
+		entry.generating = make(chan struct{})
+		p.lock.Unlock()
+
+		// cached by a previous run, else rendered
+		entry = p.load(path)
+		if entry == nil {
+			var rc *fasthttp.RequestCtx
+
+			entry, rc = p.generate(path, params)
+
+			// not cacheable (e.g. not found), the response is sent as is
+			if entry == nil {
+				rc.Response.CopyTo(&ctx.Response)
+				return
+			}
+		}
+
+		p.write(ctx, entry.body, entry.etag, entry.modified)
+
+		return
+	}
+}
+
+func (p *staticPage) stale(entry *staticEntry) bool {
+	return p.revalidate > 0 && time.Since(entry.modified) > p.revalidate
+}
+
+// write a cached page with its cache headers
+func (p *staticPage) write(ctx *fasthttp.RequestCtx, body []byte, etag string, modified time.Time) {
+	if p.revalidate > 0 {
+		seconds := strconv.Itoa(max(1, int(p.revalidate.Seconds())))
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "public, max-age=0, s-maxage="+seconds+", stale-while-revalidate="+seconds)
+	} else {
+		ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "public, max-age=0, must-revalidate")
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderETag, etag)
+	ctx.Response.Header.Set(fasthttp.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+
+	if match := ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch); len(match) > 0 && bytes.Contains(match, []byte(etag)) {
+		ctx.SetStatusCode(http.StatusNotModified)
+		return
+	}
+
+	ctx.SetContentType(MTextHtmlUTF8)
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.SetBody(body)
+}
+
+// the page cache, under the workdir of its build
+func (p *staticPage) dir() string {
+	return filepath.Join(svelteWorkdir, p.page.currentBuildId(), "static")
+}
+
+// the file of a path in the page cache
+func (p *staticPage) file(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(p.dir(), hex.EncodeToString(sum[:8])+".html")
+}
+
+// load the page rendered by a previous run
+func (p *staticPage) load(path string) *staticEntry {
+	file := p.file(path)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil
+	}
+
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	return p.store(path, body, info.ModTime())
+}
+
+// render the page for a path, the entry is nil if the
+// response isn't a 200 (the rendering context is returned)
+func (p *staticPage) generate(path string, params map[string]interface{}) (*staticEntry, *fasthttp.RequestCtx) {
+	rc := new(fasthttp.RequestCtx)
+	rc.Request.Header.SetMethod(MGet)
+	rc.Request.SetRequestURI(path)
+
+	for key, value := range params {
+		rc.SetUserValue(key, value)
+	}
+
+	rc.SetUserValue(routeKey{}, p.pattern)
+	setRequestID(rc)
+
+	if safeRun(p.logger, "static page "+path, func() { p.render(rc) }) {
+		rc.Response.Reset()
+		rc.SetStatusCode(http.StatusInternalServerError)
+	}
+
+	if rc.Response.StatusCode() != http.StatusOK {
+		p.store(path, nil, time.Time{})
+		return nil, rc
+	}
+
+	body := append([]byte(nil), rc.Response.Body()...)
+	modified := time.Now()
+
+	if err := p.save(path, body); err != nil {
+		p.logger.Warn("cannot cache static page", slog.String("path", path), slog.Any("error", err))
+	}
+
+	return p.store(path, body, modified), rc
+}
+
+// write the page file, it's renamed so a
+// concurrent run never read a partial file
+func (p *staticPage) save(path string, body []byte) error {
+	dir := p.dir()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p.file(path))
+}
+
+// update the entry of a path at the end of a rendering, a nil
+// body keep the previous one, or remove the entry if there is none
+// (e.g. a not found path isn't kept)
+func (p *staticPage) store(path string, body []byte, modified time.Time) *staticEntry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry := p.entries[path]
+	if entry == nil {
+		entry = p.add(path)
+	}
+
+	if body == nil && entry.body == nil {
+		delete(p.entries, path)
+		p.recent.Remove(entry.element)
+	}
+
+	if body != nil {
+		sum := sha256.Sum256(body)
+
+		entry.body = body
+		entry.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+		entry.modified = modified
+	}
+
+	if entry.generating != nil {
+		close(entry.generating)
+		entry.generating = nil
+	}
+
+	p.evict()
+
+	// a copy, the entry can be updated by a regeneration
+	snapshot := *entry
+
+	return &snapshot
+}
+
+// add the entry of a path, the lock must be held
+func (p *staticPage) add(path string) *staticEntry {
+	entry := &staticEntry{element: p.recent.PushFront(path)}
+	p.entries[path] = entry
+
+	return entry
+}
+
+// remove the least recently used entries above
+// staticCacheSize, the lock must be held
+func (p *staticPage) evict() {
+	for element := p.recent.Back(); element != nil && len(p.entries) > staticCacheSize; {
+		previous := element.Prev()
+
+		// the renderings in progress are kept
+		if entry := p.entries[element.Value.(string)]; entry.generating == nil {
+			delete(p.entries, element.Value.(string))
+			p.recent.Remove(element)
+		}
+
+		element = previous
+	}
+}
+
+// render again a path in the background, the lock must be held
+func (p *staticPage) regenerate(path string, entry *staticEntry) {
+	entry.generating = make(chan struct{})
+
+	go func() {
+		if _, rc := p.generate(path, entry.params); rc.Response.StatusCode() != http.StatusOK {
+			p.logger.Warn("cannot regenerate static page", slog.String("path", path), slog.Int("status", rc.Response.StatusCode()))
+		}
+	}()
+}
+
+// regenerate a path if it's cached, true is returned if it was
+func (p *staticPage) invalidate(path string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if entry := p.entries[path]; entry != nil && entry.body != nil {
+		if entry.generating == nil {
+			p.regenerate(path, entry)
+		}
+
+		return true
+	}
+
+	// cached by a previous run, it'll be rendered on the next request
+	if err := os.Remove(p.file(path)); err == nil {
+		return true
+	}
+
+	return false
+}
+
+// copy the route params of a request (set by the router)
+func routeParams(ctx *fasthttp.RequestCtx) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	ctx.VisitUserValues(func(key []byte, value interface{}) {
+		if s, ok := value.(string); ok {
+			params[string(key)] = strings.Clone(s)
+		}
+	})
+
+	return params
+}
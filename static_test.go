@@ -0,0 +1,162 @@
+package gosvelt
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// a static page served on /blog/:slug, its renderings are counted
+// and the "missing" slug is not found
+func newBlogPage(t *testing.T, revalidate time.Duration) (*GoSvelt, *staticPage, *atomic.Int32) {
+	t.Helper()
+
+	renders := new(atomic.Int32)
+
+	render := func(ctx *fasthttp.RequestCtx) {
+		slug := ctx.UserValue("slug").(string)
+		if slug == "missing" {
+			ctx.SetStatusCode(http.StatusNotFound)
+			return
+		}
+
+		ctx.SetBodyString(fmt.Sprintf("%s v%d", slug, renders.Add(1)))
+	}
+
+	gs := New()
+
+	svelte := &sveltePage{buildId: "static-test-" + strconv.FormatInt(time.Now().UnixNano(), 36)}
+	page := gs.newStaticPage("/blog/:slug", svelte, render, &SvelteOptions{revalidate: revalidate})
+
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(page.dir())) })
+
+	gs.handle(MGet, "/blog/:slug", page.serve)
+
+	return gs, page, renders
+}
+
+func TestStaticPageCache(t *testing.T) {
+	gs, page, renders := newBlogPage(t, 0)
+
+	first := serveRequest(gs, MGet, "/blog/hello", "")
+	second := serveRequest(gs, MGet, "/blog/hello", "")
+
+	if string(first.Body()) != "hello v1" || string(second.Body()) != "hello v1" || renders.Load() != 1 {
+		t.Fatalf("bodies %q %q after %d renderings", first.Body(), second.Body(), renders.Load())
+	}
+
+	etag := string(second.Header.Peek(fasthttp.HeaderETag))
+	if res := serveRequest(gs, MGet, "/blog/hello", "", "If-None-Match", etag); res.StatusCode() != http.StatusNotModified {
+		t.Errorf("If-None-Match: status = %d", res.StatusCode())
+	}
+
+	// a not found path is sent but not kept
+	if res := serveRequest(gs, MGet, "/blog/missing", ""); res.StatusCode() != http.StatusNotFound {
+		t.Errorf("missing: status = %d", res.StatusCode())
+	}
+
+	if _, ok := page.entries["/blog/missing"]; ok {
+		t.Error("the not found path is cached")
+	}
+
+	// the next run load the saved page instead of rendering it
+	gs2 := New()
+	reloaded := gs2.newStaticPage("/blog/:slug", page.page, func(ctx *fasthttp.RequestCtx) {
+		t.Error("the saved page was rendered again")
+	}, &SvelteOptions{})
+	gs2.handle(MGet, "/blog/:slug", reloaded.serve)
+
+	if res := serveRequest(gs2, MGet, "/blog/hello", ""); string(res.Body()) != "hello v1" {
+		t.Errorf("reloaded body = %q", res.Body())
+	}
+}
+
+func TestStaticPageEviction(t *testing.T) {
+	gs, page, _ := newBlogPage(t, 0)
+
+	for i := 0; i < staticCacheSize; i++ {
+		serveRequest(gs, MGet, "/blog/"+strconv.Itoa(i), "")
+	}
+
+	// 0 is used again, so 1 is now the least recently used
+	serveRequest(gs, MGet, "/blog/0", "")
+	serveRequest(gs, MGet, "/blog/new", "")
+
+	if len(page.entries) != staticCacheSize || page.recent.Len() != staticCacheSize {
+		t.Fatalf("%d entries, %d recent paths", len(page.entries), page.recent.Len())
+	}
+
+	if _, ok := page.entries["/blog/1"]; ok {
+		t.Error("the least recently used path is kept")
+	}
+
+	for _, path := range []string{"/blog/0", "/blog/2", "/blog/new"} {
+		if _, ok := page.entries[path]; !ok {
+			t.Errorf("%s was evicted", path)
+		}
+	}
+
+	if front := page.recent.Front().Value.(string); front != "/blog/new" {
+		t.Errorf("most recently used = %s", front)
+	}
+}
+
+func TestStaticPageRevalidation(t *testing.T) {
+	gs, _, renders := newBlogPage(t, 50*time.Millisecond)
+
+	res := serveRequest(gs, MGet, "/blog/hello", "")
+	if cc := string(res.Header.Peek(fasthttp.HeaderCacheControl)); cc != "public, max-age=0, s-maxage=1, stale-while-revalidate=1" {
+		t.Errorf("Cache-Control = %s", cc)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// the stale page is sent while it's regenerated
+	if res := serveRequest(gs, MGet, "/blog/hello", ""); string(res.Body()) != "hello v1" {
+		t.Errorf("stale body = %q", res.Body())
+	}
+
+	waitBody(t, gs, "/blog/hello", "hello v2")
+
+	// on demand
+	if err := gs.Revalidate("/blog/hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitBody(t, gs, "/blog/hello", "hello v3")
+
+	if err := gs.Revalidate("/blog/unknown"); err == nil {
+		t.Error("revalidating a path which is not cached must fail")
+	}
+
+	if n := renders.Load(); n != 3 {
+		t.Errorf("%d renderings", n)
+	}
+}
+
+// wait until the path is served with body
+func waitBody(t *testing.T, gs *GoSvelt, path, body string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		res := serveRequest(gs, MGet, path, "")
+		if string(res.Body()) == body {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: body = %q, want %q", path, res.Body(), body)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/4lxprime/gitdl"
 )
@@ -16,6 +19,13 @@ const (
 	svelteEnv     = "./.svelte_env"
 	svelteWorkdir = "./.svelte_workdir"
 	svelteApp     = "App.svelte"
+	svelteLayout  = "+layout.svelte" // layout of the pages of its folder
+	svelteRoot    = "Root.svelte"    // generated when the page has layouts
+
+	svelteBuilds      = "builds"        // build workspaces, under the svelte env
+	svelteEnvLock     = ".gosvelt.lock" // lock of the svelte env, across processes
+	svelteCheckConfig = "tsconfig.gosvelt.json"
+	sveltePropsId     = "gosvelt-props" // id of the props script (see Context.SvelteProps)
 )
 
 func pathFromSvelteEnv(path string) string {
@@ -23,9 +33,13 @@ func pathFromSvelteEnv(path string) string {
 }
 
 func execFromSvelteEnv(name string, args ...string) error {
+	return execFromDir(svelteEnv, name, args...)
+}
+
+func execFromDir(dir, name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 
-	cmd.Dir = svelteEnv
+	cmd.Dir = dir
 
 	if err := cmd.Run(); err != nil {
 		return err
@@ -48,13 +62,21 @@ var (
 	errPMI = func(packageManager string) error {
 		return fmt.Errorf("svelte: %s cannot install needed dependencies on your system, if you are on linux, may you can try to install it manually with '%s i' in the directory %s", packageManager, packageManager, svelteEnv)
 	}
-	errCustomMainTs     = fmt.Errorf("svelte: cannot write custom app in %s", pathFromSvelteEnv("/src/main.ts"))
+	errCustomMainTs     = fmt.Errorf("svelte: cannot write custom app in src/main.ts")
 	errCustomViteEnvTs  = fmt.Errorf("svelte: cannot write custom global.d.ts")
+	errCustomServerTs   = fmt.Errorf("svelte: cannot write custom server in src/server.ts")
+	errCustomApiTs      = fmt.Errorf("svelte: cannot write the api client in src/api/index.ts")
 	errCustomGlobaldTs  = fmt.Errorf("svelte: cannot write custom global.d.ts")
 	errNoDefaultApp     = fmt.Errorf("svelte: no default app found (%s)", svelteApp)
 	errNpxRollupCompile = fmt.Errorf("svelte: cannot compile %s with rollup, maybe you have a error in your svelte files, you may also have tried to use gs.Svelte('/path', '/your/app.svelte', ...) but it seems that app.svelte requires a parent file and to fix this, you can try using gs.AdvancedSvelte() instead", svelteEnv)
-	errViteCompile      = func(packageManager string) error {
-		return fmt.Errorf("svelte: vite: cannot compile %s, you may have an error in your svelte files or maybe you did give the wrong file path. you can get the full error by doing `%s run build` in %s", svelteEnv, packageManager, svelteEnv)
+	errViteCompile      = func(packageManager, workspace string) error {
+		return fmt.Errorf("svelte: vite: cannot compile %s, you may have an error in your svelte files or maybe you did give the wrong file path. you can get the full error by doing `%s run build` in %s", workspace, packageManager, workspace)
+	}
+	errViteSSRCompile = func(workspace string) error {
+		return fmt.Errorf("svelte: vite: cannot compile the ssr bundle of %s, you can get the full error by doing `node node_modules/vite/bin/vite.js build --ssr src/server.ts --outDir dist-ssr` in %s", workspace, workspace)
+	}
+	errSvelteCheck = func(workspace string) error {
+		return fmt.Errorf("svelte: type check failed, your svelte files may not match the api types. you can get the full error by doing `npx svelte-check --tsconfig ./%s` in %s", svelteCheckConfig, workspace)
 	}
 	errCustomTailwind = fmt.Errorf("svelte: cannot write custom postcss config in %s", pathFromSvelteEnv("/postcss.config.js"))
 	errCustomPostcss  = fmt.Errorf("svelte: cannot write custom tailwindcss config in %s", pathFromSvelteEnv("/tailwind.config.js"))
@@ -65,6 +87,13 @@ type SvelteOptions struct {
 	tailwindcss    bool
 	packageManager string
 	rootFolder     *string
+	middlewares    []SvelteMiddlewareFunc
+	layouts        []string
+	apiClient      *string // generated typescript client
+	ssr            bool
+	prerender      bool
+	revalidate     time.Duration
+	template       *string
 }
 type SvelteOption func(*SvelteOptions)
 
@@ -72,6 +101,55 @@ var (
 	WithTailwindcss = func(o *SvelteOptions) {
 		o.tailwindcss = true
 	}
+	// wrap the page in a layout component (with a <slot />), the
+	// first one is the outermost, the path is relative to the root
+	// folder if any (+layout.svelte files in the root folder and the
+	// page folders are also used as layouts)
+	//
+	//	like this:
+	//	app.Svelte("/", "App.svelte", handler, gosvelt.WithRoot("views"), gosvelt.WithLayout("Layout.svelte"))
+	WithLayout = func(layout string) SvelteOption {
+		return func(o *SvelteOptions) {
+			o.layouts = append(o.layouts, layout)
+		}
+	}
+	// render the page on the server (in the "head" and "body" values
+	// of the svelte map) and hydrate it on the client
+	//
+	//	like this:
+	//	<head>&{head}<link rel='stylesheet' href='&{css}'><script type='module' src='&{js}'></script></head>
+	//	<body>&{body}</body>
+	WithSSR = func(o *SvelteOptions) {
+		o.ssr = true
+	}
+	// render the page once to a cached html file (SSG), pages without
+	// params are rendered before the start and the other ones on their
+	// first request, the handler must not depend on the request (the
+	// middlewares run on every request, before the cached page is sent)
+	WithPrerender = func(o *SvelteOptions) {
+		o.prerender = true
+	}
+	// like WithPrerender but the page is regenerated in the background
+	// when it's older than d (ISR), see also GoSvelt.Revalidate
+	//
+	//	like this:
+	//	app.Svelte("/blog/:slug", "views/Post.svelte", postHandler, gosvelt.WithRevalidate(time.Minute))
+	WithRevalidate = func(d time.Duration) SvelteOption {
+		return func(o *SvelteOptions) {
+			o.revalidate = d
+		}
+	}
+	// the html template given to Context.Html by the page handler, it's
+	// checked when the page is registered and a template loading the js
+	// bundle as a classic script (not a module) make the start fail
+	//
+	//	like this:
+	//	app.Svelte("/", "views/App.svelte", handler, gosvelt.WithTemplate("assets/index.html"))
+	WithTemplate = func(file string) SvelteOption {
+		return func(o *SvelteOptions) {
+			o.template = &file
+		}
+	}
 	WithPackageManager = func(packageManager string) SvelteOption {
 		return func(o *SvelteOptions) {
 			o.packageManager = packageManager
@@ -82,8 +160,23 @@ var (
 			o.rootFolder = &rootFolder
 		}
 	}
+	// used by gosvelt to give the generated api client
+	withApiClient = func(client string) SvelteOption {
+		return func(o *SvelteOptions) {
+			o.apiClient = &client
+		}
+	}
+	// middlewares that only wrap this svelte page
+	WithMiddlewares = func(mws ...SvelteMiddlewareFunc) SvelteOption {
+		return func(o *SvelteOptions) {
+			o.middlewares = append(o.middlewares, mws...)
+		}
+	}
 )
 
+// compile a svelte file, the build id and the build folder (with the
+// bundles) are returned, it's safe to build several files concurrently:
+// every build has its own workspace and a same build is done once
 func BuildSvelte(inputSvelteFile string, options ...SvelteOption) (string, string, error) {
 	opts := new(SvelteOptions)
 
@@ -91,56 +184,113 @@ func BuildSvelte(inputSvelteFile string, options ...SvelteOption) (string, strin
 		opt(opts)
 	}
 
-	// init svelte env ->
+	// init the shared svelte env (template and modules) ->
 
 	if err := initSvelteEnv(opts); err != nil {
 		return "", "", err
 	}
 
-	// copy user svelte files to build env ->
+	// create the isolated build workspace ->
 
-	if err := copySvelteFiles(inputSvelteFile, opts); err != nil {
+	workspace, err := newSvelteWorkspace(opts, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	// copy user svelte files to the workspace ->
+
+	if err := copySvelteFiles(filepath.Join(workspace, "src"), inputSvelteFile, opts); err != nil {
+		os.RemoveAll(workspace)
 		return "", "", err
 	}
 
 	// get build unique id and break if already exists ->
 
-	buildHash, err := calculateTreeHash(filepath.Join(svelteEnv, "src", "app"))
+	buildHash, err := calculateBuildHash(workspace)
 	if err != nil {
+		os.RemoveAll(workspace)
 		return "", "", err
 	}
 
 	buildId := fmt.Sprintf("b%s", buildHash[:8])
 	buildFolder := filepath.Join(svelteWorkdir, buildId, "bundle")
 
-	if _, err := os.Stat(filepath.Join(buildFolder, "bundle.js")); !os.IsNotExist(err) {
-		return buildId, buildFolder, nil // return if already compiled
+	// a concurrent build of the same sources wait for this one
+	if err := singleBuild(buildId, func() error {
+		return buildSvelteWorkspace(workspace, buildFolder, opts, buildSvelteEnv)
+	}); err != nil {
+		// the workspace is kept to get the full error
+		return "", "", err
 	}
 
-	if err := os.MkdirAll(buildFolder, 0755); err != nil {
+	if err := os.RemoveAll(workspace); err != nil {
 		return "", "", err
 	}
 
-	// parse and install every project modules in build env + basic setup ->
+	return buildId, buildFolder, nil
+}
+
+// build a workspace to the build folder with compile, if not
+// already compiled (the build folder only exists once complete)
+func buildSvelteWorkspace(
+	workspace, buildFolder string,
+	opts *SvelteOptions,
+	compile func(workspace, outputFolder string, opts *SvelteOptions) error,
+) error {
+	if _, err := os.Stat(buildFolder); !os.IsNotExist(err) {
+		return nil // return if already compiled
+	}
+
+	// parse and install every project modules in the svelte env ->
 	// (the slow function)
 
-	if err := installSvelteModules(opts); err != nil {
-		return "", "", err
+	unlock, err := lockSvelteEnv(false)
+	if err != nil {
+		return err
 	}
 
-	// build svelte environment ->
+	err = installSvelteModules(workspace, opts)
+	unlock()
 
-	if err := buildSvelteEnv(buildFolder, opts); err != nil {
-		return "", "", err
+	if err != nil {
+		return err
 	}
 
-	// clear svelte environment ->
+	// build the workspace in a temporary folder, renamed
+	// so a concurrent process never read a partial build ->
 
-	if err := clearSvelteEnv(opts); err != nil {
-		return "", "", err
+	if err := os.MkdirAll(filepath.Dir(buildFolder), 0755); err != nil {
+		return err
 	}
 
-	return buildId, buildFolder, nil
+	tmpFolder, err := os.MkdirTemp(filepath.Dir(buildFolder), "bundle-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpFolder)
+
+	unlock, err = lockSvelteEnv(true)
+	if err != nil {
+		return err
+	}
+
+	err = compile(workspace, tmpFolder, opts)
+	unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFolder, buildFolder); err != nil {
+		// already built by another process
+		if _, statErr := os.Stat(buildFolder); statErr == nil {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
 }
 
 func initSvelteEnv(opts *SvelteOptions) error {
@@ -149,15 +299,6 @@ func initSvelteEnv(opts *SvelteOptions) error {
 		return errPMNotFound(opts.packageManager)
 	}
 
-	// 2nd step: download and init the template
-
-	// check is svelteEnv exist, else create it
-	if _, err := os.Stat(svelteEnv); os.IsNotExist(err) {
-		if err := os.MkdirAll(svelteEnv, 0755); err != nil {
-			return err
-		}
-	}
-
 	// check is svelteWorkdir exist, else create it
 	if _, err := os.Stat(svelteWorkdir); os.IsNotExist(err) {
 		if err := os.MkdirAll(svelteWorkdir, 0755); err != nil {
@@ -165,10 +306,15 @@ func initSvelteEnv(opts *SvelteOptions) error {
 		}
 	}
 
-	if fs, err := os.ReadDir(svelteEnv); err != nil {
+	// 2nd step: download the template, once for every build
+
+	unlock, err := lockSvelteEnv(false)
+	if err != nil {
 		return err
+	}
+	defer unlock()
 
-	} else if len(fs) < 1 { // empty svelte_env
+	if _, err := os.Stat(pathFromSvelteEnv("package.json")); os.IsNotExist(err) {
 		// downloading the svelte vite typescript template dirrectly
 		// from the official vitejs/vite repo with custom git folder download
 		if err := gitdl.DownloadGit(
@@ -185,49 +331,120 @@ func initSvelteEnv(opts *SvelteOptions) error {
 		); err != nil {
 			return err
 		}
+	}
 
-	} else { // svelte_env already initialized, clean
-		if err := clearSvelteEnv(opts); err != nil {
-			return err
-		}
+	return nil
+}
+
+// create a build workspace with the template files of the svelte
+// env, the node_modules folder is shared (symlinked), inputs are the
+// entries of the build (index.html if nil)
+func newSvelteWorkspace(opts *SvelteOptions, inputs []string) (string, error) {
+	builds := pathFromSvelteEnv(svelteBuilds)
+
+	if err := os.MkdirAll(builds, 0755); err != nil {
+		return "", err
+	}
+
+	workspace, err := os.MkdirTemp(builds, "ws-*")
+	if err != nil {
+		return "", err
+	}
+
+	if err := copySvelteTemplate(workspace); err != nil {
+		os.RemoveAll(workspace)
+		return "", err
+	}
+
+	// node also resolve the modules from the parent
+	// folders, the symlink is only needed by the scripts
+	if modules, err := filepath.Abs(pathFromSvelteEnv("node_modules")); err == nil {
+		os.Symlink(modules, filepath.Join(workspace, "node_modules"))
+	}
+
+	if err := os.MkdirAll(filepath.Join(workspace, "src"), 0755); err != nil {
+		os.RemoveAll(workspace)
+		return "", err
 	}
 
 	// writing the vite-env.d.ts, which will be used
 	// to reference typing
 	if err := os.WriteFile(
-		pathFromSvelteEnv("/src/vite-env.d.ts"),
+		filepath.Join(workspace, "src", "vite-env.d.ts"),
 		[]byte(`/// <reference types="svelte" />
 /// <reference types="vite/client" />`),
 		0644,
 	); err != nil {
-		return errCustomViteEnvTs
+		os.RemoveAll(workspace)
+		return "", errCustomViteEnvTs
 	}
 
-	if opts.tailwindcss {
-		// writing tailwindcss config to vite config
-		if err := os.WriteFile(
-			pathFromSvelteEnv("vite.config.ts"),
-			[]byte(`import tailwind from'tailwindcss';import autoprefixer from'autoprefixer';import{defineConfig}from'vite';import{svelte}from'@sveltejs/vite-plugin-svelte';export default defineConfig({plugins:[svelte()],css:{postcss:{plugins:[tailwind({content:["./**/*.svelte"]}),autoprefixer]}}});`),
-			0644,
-		); err != nil {
-			return fmt.Errorf("svelte: error while writing custom tailwindcss/postcss config (%s)", pathFromSvelteEnv("vite.config.ts"))
+	// writing the generated api client, imported with $api
+	if opts.apiClient != nil {
+		if err := os.MkdirAll(filepath.Join(workspace, "src", "api"), 0755); err != nil {
+			os.RemoveAll(workspace)
+			return "", err
 		}
 
-	} else {
-		// writing default vite config
 		if err := os.WriteFile(
-			pathFromSvelteEnv("vite.config.ts"),
-			[]byte(`import{defineConfig}from'vite';import{svelte}from'@sveltejs/vite-plugin-svelte';export default defineConfig({plugins:[svelte()]});`),
+			filepath.Join(workspace, "src", "api", "index.ts"),
+			[]byte(*opts.apiClient),
 			0644,
 		); err != nil {
-			return fmt.Errorf("svelte: error while writing custom tailwindcss/postcss config (%s)", pathFromSvelteEnv("vite.config.ts"))
+			os.RemoveAll(workspace)
+			return "", errCustomApiTs
+		}
+	}
+
+	// writing the vite config (tailwindcss/postcss, $api alias, ...)
+	if err := writeViteConfig(workspace, opts, inputs); err != nil {
+		os.RemoveAll(workspace)
+		return "", err
+	}
+
+	return workspace, nil
+}
+
+// copy the template files of the svelte env (package.json,
+// index.html, tsconfig.json, ...) to a workspace
+func copySvelteTemplate(workspace string) error {
+	unlock, err := lockSvelteEnv(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := os.ReadDir(svelteEnv)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch name := entry.Name(); name {
+		case "node_modules", svelteBuilds, svelteEnvLock, "src", "dist", "dist-ssr":
+			continue
+
+		default:
+			if entry.IsDir() {
+				if err := copyDir(pathFromSvelteEnv(name), filepath.Join(workspace, name)); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := copyFile(pathFromSvelteEnv(name), filepath.Join(workspace, name)); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-func copySvelteFiles(inputSvelteFile string, opts *SvelteOptions) error {
+// copy a page to a src folder of a workspace, with its
+// layouts and its main.ts and server.ts entries
+func copySvelteFiles(srcFolder, inputSvelteFile string, opts *SvelteOptions) error {
 	var rootFolder string
 
 	if opts.rootFolder == nil {
@@ -243,7 +460,7 @@ func copySvelteFiles(inputSvelteFile string, opts *SvelteOptions) error {
 		return fmt.Errorf("svelte: default app not found (%s)", inputSvelteAppFile)
 	}
 
-	svelteAppFolder := filepath.Join(svelteEnv, "src", "app")
+	svelteAppFolder := filepath.Join(srcFolder, "app")
 	svelteAppFile := svelteApp
 
 	if opts.rootFolder == nil { // if there is no root folder
@@ -277,28 +494,152 @@ func copySvelteFiles(inputSvelteFile string, opts *SvelteOptions) error {
 		}
 	}
 
+	// the page is mounted in its layouts (if any)
+	entry := "./app/" + filepath.ToSlash(svelteAppFile)
+
+	layouts, err := copySvelteLayouts(srcFolder, inputSvelteFile, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(layouts) > 0 {
+		if err := writeSvelteRoot(srcFolder, entry, layouts); err != nil {
+			return err
+		}
+
+		entry = "./" + svelteRoot
+	}
+
 	// writing the main.ts file, which will be used
 	// to give svelte files to the vite compiler
+	mainTs := fmt.Sprintf(
+		"import App from '%s'; const props = document.getElementById('%s'); export default new App({ target: document.body, props: props ? JSON.parse(props.textContent || '{}') : {} });",
+		entry,
+		sveltePropsId,
+	)
+
+	if opts.ssr { // hydrate the server rendered page
+		mainTs = fmt.Sprintf(
+			"import App from '%s'; const props = document.getElementById('%s'); const target = document.getElementById('%s'); export default new App({ target: target || document.body, hydrate: !!target, props: props ? JSON.parse(props.textContent || '{}') : {} });",
+			entry,
+			sveltePropsId,
+			ssrAppId,
+		)
+	}
+
+	if err := os.WriteFile(filepath.Join(srcFolder, "main.ts"), []byte(mainTs), 0644); err != nil {
+		return errCustomMainTs
+	}
+
+	// writing the server.ts file, the entry of the ssr build
+	if opts.ssr {
+		if err := os.WriteFile(
+			filepath.Join(srcFolder, "server.ts"),
+			[]byte(fmt.Sprintf(
+				"import App from '%s'; export function render(props: Record<string, unknown>) { return (App as any).render(props); }",
+				entry,
+			)),
+			0644,
+		); err != nil {
+			return errCustomServerTs
+		}
+	}
+
+	return nil
+}
+
+// the layouts of a page (outermost first), the WithLayout ones then
+// the +layout.svelte found from the root folder to the page folder,
+// their paths are returned relative to the src folder
+func copySvelteLayouts(srcFolder, inputSvelteFile string, opts *SvelteOptions) ([]string, error) {
+	var layouts []string
+
+	for i, layout := range opts.layouts {
+		// already copied with the root folder
+		if opts.rootFolder != nil {
+			layouts = append(layouts, "./app/"+filepath.ToSlash(filepath.Clean(layout)))
+			continue
+		}
+
+		dest := filepath.Join("layouts", strconv.Itoa(i), filepath.Base(layout))
+
+		if err := copyFile(layout, filepath.Join(srcFolder, dest)); err != nil {
+			return nil, fmt.Errorf("svelte: layout not found (%s)", layout)
+		}
+
+		layouts = append(layouts, "./"+filepath.ToSlash(dest))
+	}
+
+	if opts.rootFolder == nil {
+		return layouts, nil
+	}
+
+	// e.g. blog/posts/App.svelte -> ., blog, blog/posts
+	dir := filepath.ToSlash(filepath.Dir(filepath.Clean(inputSvelteFile)))
+	dirs := []string{"."}
+
+	if dir != "." {
+		segments := strings.Split(dir, "/")
+		for i := range segments {
+			dirs = append(dirs, strings.Join(segments[:i+1], "/"))
+		}
+	}
+
+	for _, dir := range dirs {
+		layout := path.Join(dir, svelteLayout)
+
+		if _, err := os.Stat(filepath.Join(srcFolder, "app", layout)); err == nil {
+			layouts = append(layouts, "./app/"+layout)
+		}
+	}
+
+	return layouts, nil
+}
+
+// write the root component mounting the page in its layouts,
+// every component get the props
+//
+//	like this:
+//	<Layout0 {...$$props}><Layout1 {...$$props}><Page {...$$props} /></Layout1></Layout0>
+func writeSvelteRoot(srcFolder, page string, layouts []string) error {
+	var script, markup strings.Builder
+
+	for i, layout := range layouts {
+		fmt.Fprintf(&script, "\timport Layout%d from '%s';\n", i, layout)
+		fmt.Fprintf(&markup, "<Layout%d {...$$props}>", i)
+	}
+
+	fmt.Fprintf(&script, "\timport Page from '%s';\n", page)
+	markup.WriteString("<Page {...$$props} />")
+
+	for i := len(layouts) - 1; i >= 0; i-- {
+		fmt.Fprintf(&markup, "</Layout%d>", i)
+	}
+
+	file := filepath.Join(srcFolder, svelteRoot)
+
 	if err := os.WriteFile(
-		pathFromSvelteEnv("src/main.ts"),
-		[]byte(fmt.Sprintf(
-			"import App from './app/%s'; export default new App({ target: document.body });",
-			filepath.ToSlash(svelteAppFile),
-		)),
+		file,
+		[]byte("<script>\n"+script.String()+"</script>\n\n"+markup.String()+"\n"),
 		0644,
 	); err != nil {
-		return errCustomMainTs
+		return fmt.Errorf("svelte: cannot write the layouts root in %s", file)
 	}
 
 	return nil
 }
 
-func installSvelteModules(opts *SvelteOptions) error {
-	if err := execFromSvelteEnv(opts.packageManager, "i"); err != nil {
-		return errPMI(opts.packageManager)
+// install the modules in the svelte env, the svelte env
+// must be locked (see lockSvelteEnv)
+func installSvelteModules(workspace string, opts *SvelteOptions) error {
+	// skipped if node_modules is newer than package.json
+	if !isUpToDate(pathFromSvelteEnv("node_modules"), pathFromSvelteEnv("package.json")) {
+		if err := execFromSvelteEnv(opts.packageManager, "i"); err != nil {
+			return errPMI(opts.packageManager)
+		}
 	}
 
-	if opts.tailwindcss {
+	if _, err := os.Stat(pathFromSvelteEnv("node_modules/tailwindcss")); opts.tailwindcss && os.IsNotExist(err) {
 		// install needed tailwindcss deps
 		if err := execFromSvelteEnv(
 			opts.packageManager,
@@ -311,97 +652,199 @@ func installSvelteModules(opts *SvelteOptions) error {
 		}
 	}
 
-	return moduleParser(opts)
+	return moduleParser(workspace, opts)
 }
 
-func buildSvelteEnv(outputFolder string, opts *SvelteOptions) error {
-	if err := execFromSvelteEnv(opts.packageManager, "run", "build"); err != nil {
-		return errViteCompile(opts.packageManager)
+func buildSvelteEnv(workspace, outputFolder string, opts *SvelteOptions) error {
+	// vite only strip the types, so the svelte files are checked
+	// against the generated api client before the build
+	if opts.apiClient != nil {
+		if err := checkSvelteEnv(workspace); err != nil {
+			return err
+		}
+	}
+
+	if err := execFromDir(workspace, opts.packageManager, "run", "build"); err != nil {
+		return errViteCompile(opts.packageManager, workspace)
 	}
 
-	assetFolder := filepath.Join(svelteEnv, "dist", "assets", "")
+	// the emitted files are read from the manifest, the
+	// entry is copied to bundle.js and its css to bundle.css
+	dist := filepath.Join(workspace, "dist")
 
-	matches, err := filepath.Glob(filepath.Join(assetFolder, "index-*.*"))
+	manifest, err := copyViteBuild(dist, outputFolder)
 	if err != nil {
 		return err
 	}
 
-	var noCss bool
-
-	if len(matches) != 2 {
-		if len(matches) != 1 {
-			return fmt.Errorf("svelte: could not found generated bundles, builder error")
-		}
+	entry, ok := manifest["index.html"]
+	if !ok {
+		return fmt.Errorf("svelte: could not found generated bundles, builder error")
+	}
 
-		noCss = true
+	if err := copyFile(
+		filepath.Join(dist, filepath.FromSlash(entry.File)),
+		filepath.Join(outputFolder, "bundle.js"),
+	); err != nil {
+		return err
 	}
 
-	var jsFile string
-	var cssFile string
+	if err := writeViteCss(dist, manifest, entry, filepath.Join(outputFolder, "bundle.css")); err != nil {
+		return err
+	}
 
-	if filepath.Ext(matches[0]) == ".js" {
-		jsFile = matches[0]
-		if !noCss {
-			cssFile = matches[1]
+	// build and copy the server bundle
+	if opts.ssr {
+		if err := buildSvelteServer(workspace, "src", filepath.Join(outputFolder, "server.mjs")); err != nil {
+			return err
 		}
+	}
 
-	} else { // else noCss won't match so no need to check in !noCss
-		jsFile = matches[1]
-		cssFile = matches[0]
+	return nil
+}
+
+// build the server.ts entry of a src folder (e.g. src/p0)
+// of a workspace to outputFile
+func buildSvelteServer(workspace, srcFolder, outputFile string) error {
+	vite, err := filepath.Abs(pathFromSvelteEnv(filepath.Join("node_modules", "vite", "bin", "vite.js")))
+	if err != nil {
+		return err
 	}
 
-	// copy js index to the bundle output
-	if err := copyFile(
-		jsFile,
-		filepath.Join(outputFolder, "bundle.js"),
+	outDir := path.Join("dist-ssr", strings.TrimPrefix(srcFolder, "src"))
+
+	if err := execFromDir(
+		workspace,
+		"node",
+		vite,
+		"build",
+		"--ssr", path.Join(srcFolder, "server.ts"),
+		"--outDir", outDir,
 	); err != nil {
-		return err
+		return errViteSSRCompile(workspace)
 	}
 
-	// copy css index to the bundle output
-	if !noCss {
-		if err := copyFile(
-			cssFile,
-			filepath.Join(outputFolder, "bundle.css"),
-		); err != nil {
-			return err
-		}
+	return copyFile(filepath.Join(workspace, filepath.FromSlash(outDir), "server.js"), outputFile)
+}
 
-	} else { // writing empty css bundle file
-		file, err := os.Create(filepath.Join(outputFolder, "bundle.css"))
-		if err != nil {
-			return nil
+// write the vite config of a workspace, the $api alias
+// point to the generated typescript client (see GoSvelt.TypeScript),
+// the inputs are the entries of a multi-page build (see WithBatchBuild)
+//
+//	like this:
+//	writeViteConfig(workspace, opts, []string{"src/p0/main.ts", "src/p1/main.ts"})
+func writeViteConfig(workspace string, opts *SvelteOptions, inputs []string) error {
+	imports := `import{fileURLToPath}from'node:url';import{defineConfig}from'vite';import{svelte}from'@sveltejs/vite-plugin-svelte';`
+	// the assets are referenced relatively since they
+	// are served under the build id (see copyViteBuild)
+	config := `base:'./',plugins:[svelte()],resolve:{alias:{$api:fileURLToPath(new URL('./src/api/index.ts',import.meta.url))}}`
+
+	if opts.ssr {
+		// svelte is bundled in the server bundle so it can run from the workdir
+		config = `base:'./',plugins:[svelte({compilerOptions:{hydratable:true}})],ssr:{noExternal:true},resolve:{alias:{$api:fileURLToPath(new URL('./src/api/index.ts',import.meta.url))}}`
+	}
+
+	if opts.tailwindcss {
+		imports = `import tailwind from'tailwindcss';import autoprefixer from'autoprefixer';` + imports
+		config += `,css:{postcss:{plugins:[tailwind({content:["./**/*.svelte"]}),autoprefixer]}}`
+	}
+
+	if len(inputs) > 0 {
+		entries := make([]string, len(inputs))
+		for i, input := range inputs {
+			entries[i] = fmt.Sprintf("%q:fileURLToPath(new URL('./%s',import.meta.url))", svelteBatchEntry(i), input)
 		}
-		file.Close()
+
+		config += `,build:{manifest:true,rollupOptions:{input:{` + strings.Join(entries, ",") + `}}}`
+
+	} else {
+		config += `,build:{manifest:true}`
+	}
+
+	file := filepath.Join(workspace, "vite.config.ts")
+
+	if err := os.WriteFile(
+		file,
+		[]byte(imports+"export default defineConfig({"+config+"});"),
+		0644,
+	); err != nil {
+		return fmt.Errorf("svelte: error while writing custom tailwindcss/postcss config (%s)", file)
 	}
 
 	return nil
 }
 
-func clearSvelteEnv(opts *SvelteOptions) error {
-	_ = opts
+// an import of the api client (e.g. import { getUsers } from '$api')
+var svelteApiImport = regexp.MustCompile(`(?:from|import)\s*\(?\s*['"]\$api['"]`)
 
-	if err := cleanDir(filepath.Join(svelteEnv, "dist")); err != nil {
-		return err
+// type check the svelte app and the api client with svelte-check
+// (installed by the vite template), skipped if it's not installed
+// or if the app doesn't use the api client
+func checkSvelteEnv(workspace string) error {
+	if !importsSvelteApi(workspace) {
+		return nil
 	}
 
-	if err := cleanDir(filepath.Join(svelteEnv, "src")); err != nil {
+	checker, err := filepath.Abs(pathFromSvelteEnv(filepath.Join("node_modules", "svelte-check", "bin", "svelte-check")))
+	if err != nil {
 		return err
 	}
 
-	if opts.tailwindcss {
-		if err := os.WriteFile(
-			pathFromSvelteEnv("vite.config.ts"),
-			[]byte(`import{defineConfig}from'vite';import{svelte}from'@sveltejs/vite-plugin-svelte';export default defineConfig({plugins:[svelte()]});`),
-			0644,
-		); err != nil {
-			return fmt.Errorf("svelte: error while writing custom tailwindcss/postcss config (%s)", pathFromSvelteEnv("vite.config.ts"))
-		}
+	if _, err := os.Stat(checker); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.WriteFile(
+		filepath.Join(workspace, svelteCheckConfig),
+		[]byte(`{"extends":"@tsconfig/svelte/tsconfig.json","compilerOptions":{"target":"ESNext","module":"ESNext","moduleResolution":"bundler","noEmit":true,"skipLibCheck":true,"paths":{"$api":["./src/api/index.ts"]}},"include":["src/**/app/**/*.ts","src/**/app/**/*.svelte","src/api/**/*.ts","src/*.d.ts"]}`),
+		0644,
+	); err != nil {
+		return fmt.Errorf("svelte: cannot write type check config in %s", filepath.Join(workspace, svelteCheckConfig))
+	}
+
+	if err := execFromDir(
+		workspace,
+		"node",
+		checker,
+		"--tsconfig", "./"+svelteCheckConfig,
+		"--threshold", "error",
+	); err != nil {
+		return errSvelteCheck(workspace)
 	}
 
 	return nil
 }
 
+// true if a source of the workspace imports the api client
+func importsSvelteApi(workspace string) bool {
+	found := false
+
+	filepath.WalkDir(filepath.Join(workspace, "src"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipAll
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == "api" && filepath.Dir(path) == filepath.Join(workspace, "src") {
+				return filepath.SkipDir // the client itself
+			}
+
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".svelte", ".ts", ".js":
+			if data, err := os.ReadFile(path); err == nil && svelteApiImport.Match(data) {
+				found = true
+			}
+		}
+
+		return nil
+	})
+
+	return found
+}
+
 // this will parse a svelte file for found modules
 //
 //	like this:
@@ -447,8 +890,8 @@ func parseSvelte(data string) []string {
 //
 //	like this:
 //	go moduleparser(SvelteConfig{...})
-func moduleParser(opts *SvelteOptions) error {
-	return filepath.Walk(filepath.Join(svelteEnv, "src"), func(path string, info fs.FileInfo, err error) error {
+func moduleParser(workspace string, opts *SvelteOptions) error {
+	return filepath.Walk(filepath.Join(workspace, "src"), func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,67 @@
+package gosvelt
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Typed convert a typed function to a HandlerFunc, the request is
+// bound and validated into Req (see Context.Bind) and the returned
+// Res is sent with the content negotiation of Context.Negotiate
+//
+//	like this:
+//	type GetUser struct {
+//		ID int `param:"id" validate:"min=1"`
+//	}
+//
+//	app.Get("/users/:id", gosvelt.Typed(func(c *gosvelt.Context, req GetUser) (User, error) {
+//		return db.User(c.Ctx, req.ID)
+//	}))
+//
+// NOTE: Req can be a pointer (e.g. to a proto.Message), a nil
+// pointer or interface Res send a 204 no content
+func Typed[Req, Res any](fn func(c *Context, req Req) (Res, error)) HandlerFunc {
+	reqType := reflect.TypeOf((*Req)(nil)).Elem()
+
+	return func(c *Context) error {
+		var req Req
+
+		target := interface{}(&req)
+		if reqType.Kind() == reflect.Pointer {
+			ptr := reflect.New(reqType.Elem())
+			req = ptr.Interface().(Req)
+			target = req
+		}
+
+		if err := c.Bind(target); err != nil {
+			return err
+		}
+
+		res, err := fn(c, req)
+		if err != nil {
+			return err
+		}
+
+		if isNil(res) {
+			c.SetStatusCode(http.StatusNoContent)
+			return nil
+		}
+
+		return c.Negotiate(http.StatusOK, res)
+	}
+}
+
+// true if v is nil or a nil pointer or interface, a nil
+// map or slice is a value (encoded as null)
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+
+	return false
+}
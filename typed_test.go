@@ -0,0 +1,122 @@
+package gosvelt
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+type typedUser struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type typedGetUser struct {
+	ID int `param:"id" validate:"min=1"`
+}
+
+// serve a request to h registered on /users/:id
+func typedRequest(h HandlerFunc, method, uri, body string, headers ...string) *fasthttp.Response {
+	gs := New()
+	gs.add(method, "/users/:id", h, nil)
+
+	return serveRequest(gs, method, uri, body, headers...)
+}
+
+func TestTypedResponse(t *testing.T) {
+	h := Typed(func(c *Context, req typedGetUser) (typedUser, error) {
+		return typedUser{ID: req.ID, Name: "gopher"}, nil
+	})
+
+	res := typedRequest(h, MGet, "/users/7", "")
+	if res.StatusCode() != http.StatusOK || string(res.Body()) != `{"id":7,"name":"gopher"}` {
+		t.Errorf("json: %d %s", res.StatusCode(), res.Body())
+	}
+
+	res = typedRequest(h, MGet, "/users/7", "", "Accept", MAppXML)
+	if want := `<typedUser><id>7</id><name>gopher</name></typedUser>`; !strings.HasSuffix(string(res.Body()), want) {
+		t.Errorf("xml: %s, want %s", res.Body(), want)
+	}
+}
+
+func TestTypedRequest(t *testing.T) {
+	pointer := Typed(func(c *Context, req *typedGetUser) (int, error) {
+		return req.ID * 2, nil
+	})
+
+	if res := typedRequest(pointer, MGet, "/users/4", ""); string(res.Body()) != "8" {
+		t.Errorf("pointer request: %s", res.Body())
+	}
+
+	body := Typed(func(c *Context, req typedUser) (string, error) {
+		return req.Name, nil
+	})
+
+	if res := typedRequest(body, MPost, "/users/1", `{"name":"gopher"}`, "Content-Type", MAppJSON); string(res.Body()) != `"gopher"` {
+		t.Errorf("body request: %s", res.Body())
+	}
+}
+
+func TestTypedValidation(t *testing.T) {
+	h := Typed(func(c *Context, req typedGetUser) (typedUser, error) {
+		t.Error("the handler must not be called")
+		return typedUser{}, nil
+	})
+
+	if res := typedRequest(h, MGet, "/users/0", ""); res.StatusCode() != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d", res.StatusCode())
+	}
+}
+
+func TestTypedError(t *testing.T) {
+	h := Typed(func(c *Context, req typedGetUser) (*typedUser, error) {
+		return nil, ErrNotFound
+	})
+
+	if res := typedRequest(h, MGet, "/users/1", ""); res.StatusCode() != http.StatusNotFound {
+		t.Errorf("status = %d", res.StatusCode())
+	}
+}
+
+func TestTypedEmptyResponse(t *testing.T) {
+	nilPointer := Typed(func(c *Context, req typedGetUser) (*typedUser, error) {
+		return nil, nil
+	})
+	nilInterface := Typed(func(c *Context, req typedGetUser) (interface{}, error) {
+		return nil, nil
+	})
+
+	for _, h := range []HandlerFunc{nilPointer, nilInterface} {
+		res := typedRequest(h, MGet, "/users/1", "")
+		if res.StatusCode() != http.StatusNoContent || len(res.Body()) != 0 {
+			t.Errorf("%d %s, want an empty 204", res.StatusCode(), res.Body())
+		}
+	}
+
+	// a nil slice is a value
+	nilSlice := Typed(func(c *Context, req typedGetUser) ([]typedUser, error) {
+		return nil, nil
+	})
+
+	if res := typedRequest(nilSlice, MGet, "/users/1", ""); res.StatusCode() != http.StatusOK || string(res.Body()) != "null" {
+		t.Errorf("nil slice: %d %s", res.StatusCode(), res.Body())
+	}
+}
+
+func TestIsNil(t *testing.T) {
+	var (
+		user  *typedUser
+		err   error
+		users []typedUser
+	)
+
+	if !isNil(nil) || !isNil(user) {
+		t.Error("nil and nil pointers are nil")
+	}
+
+	if isNil(&err) || isNil(users) || isNil(0) || isNil(typedUser{}) {
+		t.Error("values and nil slices are not nil")
+	}
+}
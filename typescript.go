@@ -0,0 +1,405 @@
+package gosvelt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// generate a typescript module with the request/response types of
+// the registered routes and a fetch based client, one function per
+// route named like the OpenAPI operation ids (see GoSvelt.OpenAPI)
+//
+// svelte pages get it as the `$api` module:
+//
+//	<script lang="ts">
+//		import { getUsersId, type User } from '$api';
+//
+//		let user: Promise<User> = getUsersId({ ID: 1 });
+//	</script>
+//
+// NOTE: hidden routes are excluded, and the api routes must be
+// registered before the svelte pages which use them
+func (gs *GoSvelt) TypeScript() string {
+	b := &tsBuilder{names: newTypeNames()}
+
+	var fns strings.Builder
+
+	ids := operationIDs(gs.routes)
+
+	for _, route := range gs.routes {
+		if route.hidden {
+			continue
+		}
+
+		b.function(&fns, route, ids[route])
+	}
+
+	var out strings.Builder
+
+	out.WriteString(tsHeader)
+
+	for _, decl := range b.decls {
+		out.WriteString("\n")
+		out.WriteString(decl)
+	}
+
+	out.WriteString(fns.String())
+
+	return out.String()
+}
+
+// write the generated typescript client (see GoSvelt.TypeScript)
+// to a file, useful to get the types in your editor
+//
+//	like this:
+//	app.WriteTypeScript("./src/lib/api.ts")
+func (gs *GoSvelt) WriteTypeScript(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, []byte(gs.TypeScript()), 0644)
+}
+
+type tsBuilder struct {
+	names *typeNames
+	decls []string
+}
+
+// write the client function of a route
+func (b *tsBuilder) function(w *strings.Builder, route *Route, name string) {
+	var (
+		reqType string
+		query   []string
+		header  []string
+		body    string
+		rawBody bool // the request is the whole body
+	)
+
+	// route param name -> request field name
+	params := make(map[string]string)
+
+	if route.request != nil {
+		reqType = b.typ(route.request)
+
+		if t, _ := schemaType(route.request); t.Kind() == reflect.Struct {
+			var bodyFields []string
+
+			for _, sf := range schemaFields(t) {
+				switch sf.location {
+				case "path":
+					params[sf.key] = sf.name
+				case "query":
+					query = append(query, tsProperty(sf.key)+": "+strconv.Quote(sf.name))
+				case "header":
+					header = append(header, tsProperty(sf.key)+": "+strconv.Quote(sf.name))
+				default:
+					bodyFields = append(bodyFields, strconv.Quote(sf.name))
+				}
+			}
+
+			if len(bodyFields) > 0 && hasBody(route.Method) {
+				body = "[" + strings.Join(bodyFields, ", ") + "]"
+			}
+
+		} else if hasBody(route.Method) {
+			body = "true"
+			rawBody = true
+		}
+	}
+
+	// route params without request field
+	var missing []string
+	for _, name := range pathParams(route.Path) {
+		if _, ok := params[name]; !ok {
+			params[name] = name
+			missing = append(missing, tsProperty(name)+": string")
+		}
+	}
+
+	// the route params of a raw body are given next to it,
+	// so they're not sent in the body
+	if rawBody && len(missing) > 0 {
+		key := "body"
+		for params[key] != "" {
+			key = "_" + key
+		}
+
+		reqType = "{ " + strings.Join(missing, "; ") + "; " + tsProperty(key) + ": " + reqType + " }"
+		body = strconv.Quote(key)
+
+	} else if len(missing) > 0 {
+		missingType := "{ " + strings.Join(missing, "; ") + " }"
+
+		if reqType == "" {
+			reqType = missingType
+		} else {
+			reqType += " & " + missingType
+		}
+	}
+
+	resType := "unknown"
+	switch {
+	case route.status != 0 && !bodyAllowed(route.status):
+		resType = "void"
+	case route.response != nil:
+		resType = b.typ(route.response)
+	}
+
+	// the path as a template literal
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "${encodeURIComponent(String(req" + tsAccess(params[segment[1:]]) + "))}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "${encodeURI(String(req" + tsAccess(params[segment[1:]]) + "))}"
+		default:
+			segments[i] = strings.NewReplacer("`", "\\`", "${", "\\${", "\\", "\\\\").Replace(segment)
+		}
+	}
+
+	var spec []string
+	if len(query) > 0 {
+		spec = append(spec, "query: { "+strings.Join(query, ", ")+" }")
+	}
+	if len(header) > 0 {
+		spec = append(spec, "header: { "+strings.Join(header, ", ")+" }")
+	}
+	if body != "" {
+		spec = append(spec, "body: "+body)
+	}
+
+	w.WriteString("\n")
+
+	if doc := tsDoc(route); doc != "" {
+		w.WriteString(doc)
+	}
+
+	if reqType == "" {
+		fmt.Fprintf(w, "export function %s(init?: RequestInit): Promise<%s> {\n", name, resType)
+		fmt.Fprintf(w, "\treturn request<%s>(%s, `%s`, {}, {}, init);\n}\n", resType, strconv.Quote(route.Method), strings.Join(segments, "/"))
+
+		return
+	}
+
+	fmt.Fprintf(w, "export function %s(req: %s, init?: RequestInit): Promise<%s> {\n", name, reqType, resType)
+	fmt.Fprintf(w, "\treturn request<%s>(%s, `%s`, req, { %s }, init);\n}\n", resType, strconv.Quote(route.Method), strings.Join(segments, "/"), strings.Join(spec, ", "))
+}
+
+// typescript type of a go type as encoded by encoding/json,
+// named structs are declared as interfaces
+func (b *tsBuilder) typ(t reflect.Type) string {
+	t, isText := schemaType(t)
+
+	switch {
+	case t == timeType, isText:
+		return "string"
+	case t == rawMessageType:
+		return "unknown"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+
+	case reflect.String:
+		return "string"
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string" // base64
+		}
+
+		elem := b.typ(t.Elem())
+		if strings.ContainsAny(elem, " |&") {
+			elem = "(" + elem + ")"
+		}
+
+		return elem + "[]"
+
+	case reflect.Map:
+		return "Record<string, " + b.typ(t.Elem()) + ">"
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(schemaFields(t))
+		}
+
+		name, isNew := b.names.name(t)
+		if isNew {
+			// the name is taken before the fields to allow recursive types
+			b.decls = append(b.decls, "export interface "+name+" "+b.object(schemaFields(t))+"\n")
+		}
+
+		return name
+	}
+
+	return "unknown"
+}
+
+// an object type with the json fields, omitempty fields are optional
+func (b *tsBuilder) object(fields []schemaField) string {
+	if len(fields) == 0 {
+		return "{}"
+	}
+
+	var w strings.Builder
+
+	w.WriteString("{\n")
+
+	for _, sf := range fields {
+		optional := ""
+		// params other than path ones can be missing
+		if sf.omitempty || (sf.location == "query" || sf.location == "header") && !sf.required() {
+			optional = "?"
+		}
+
+		typ := b.typ(sf.field.Type)
+		if sf.field.Type.Kind() == reflect.Pointer && optional == "" {
+			typ += " | null"
+		}
+
+		fmt.Fprintf(&w, "\t%s%s: %s;\n", tsProperty(sf.name), optional, typ)
+	}
+
+	w.WriteString("}")
+
+	return w.String()
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// an object property name, quoted if needed
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+
+	return strconv.Quote(name)
+}
+
+// a property access expression
+func tsAccess(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return "." + name
+	}
+
+	return "[" + strconv.Quote(name) + "]"
+}
+
+// the jsdoc comment of a route
+func tsDoc(route *Route) string {
+	var lines []string
+
+	for _, text := range []string{route.summary, route.description} {
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, strings.ReplaceAll(line, "*/", "*\\/"))
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "/**\n * " + strings.Join(lines, "\n * ") + "\n */\n"
+}
+
+// the runtime part of the generated client
+const tsHeader = `// Code generated by gosvelt. DO NOT EDIT.
+
+/* eslint-disable */
+
+export interface HTTPError {
+	status: number;
+	error: string;
+	details?: unknown;
+}
+
+// thrown by the client when the response is not ok
+export class ApiError extends Error {
+	readonly status: number;
+	readonly body: HTTPError | null;
+
+	constructor(status: number, body: HTTPError | null) {
+		super(body?.error ?? ` + "`request failed with status ${status}`" + `);
+		this.name = 'ApiError';
+		this.status = status;
+		this.body = body;
+	}
+}
+
+let baseUrl = '';
+
+// set the url prepended to every request path (empty by default)
+export function setBaseUrl(url: string): void {
+	baseUrl = url.replace(/\/+$/, '');
+}
+
+interface RequestSpec {
+	query?: Record<string, string>;
+	header?: Record<string, string>;
+	body?: string[] | string | true; // body fields, field holding the body or the whole request
+}
+
+async function request<T>(method: string, path: string, req: any, spec: RequestSpec, init?: RequestInit): Promise<T> {
+	const query = new URLSearchParams();
+	for (const [key, name] of Object.entries(spec.query ?? {})) {
+		const value = req[name];
+		if (value === undefined || value === null) continue;
+
+		for (const v of Array.isArray(value) ? value : [value]) query.append(key, String(v));
+	}
+
+	const headers = new Headers(init?.headers);
+	headers.set('Accept', 'application/json');
+	for (const [key, name] of Object.entries(spec.header ?? {})) {
+		const value = req[name];
+		if (value !== undefined && value !== null) headers.set(key, String(value));
+	}
+
+	let body: BodyInit | undefined;
+	if (spec.body === true) {
+		body = JSON.stringify(req);
+	} else if (typeof spec.body === 'string') {
+		body = JSON.stringify(req[spec.body]);
+	} else if (spec.body) {
+		const fields: Record<string, unknown> = {};
+		for (const name of spec.body) {
+			if (req[name] !== undefined) fields[name] = req[name];
+		}
+		body = JSON.stringify(fields);
+	}
+	if (body !== undefined) headers.set('Content-Type', 'application/json');
+
+	const search = query.toString();
+	const res = await fetch(baseUrl + path + (search ? '?' + search : ''), { ...init, method, headers, body });
+
+	if (!res.ok) {
+		let error: HTTPError | null = null;
+		try {
+			error = await res.json();
+		} catch {}
+
+		throw new ApiError(res.status, error);
+	}
+
+	if (res.status === 204) return undefined as T;
+
+	if ((res.headers.get('Content-Type') ?? '').includes('json')) return res.json();
+
+	return (await res.text()) as T;
+}
+`
@@ -0,0 +1,126 @@
+package gosvelt
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type tsPage[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+// the generated declaration starting with prefix (a function
+// or an interface), its doc comment excluded
+func tsDecl(t *testing.T, ts, prefix string) string {
+	t.Helper()
+
+	start := strings.Index(ts, "\n"+prefix)
+	if start < 0 {
+		t.Fatalf("no %s in\n%s", prefix, ts[len(tsHeader):])
+	}
+
+	decl := ts[start+1:]
+
+	return decl[:strings.Index(decl, "\n}\n")+3]
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	handler := func(c *Context) error { return nil }
+
+	gs := New()
+
+	gs.Get("/health", handler).Response("")
+	gs.Get("/users/:id", handler).Response(openAPIUser{})
+	gs.Put("/users/:id", handler).Request(openAPIUpdateUser{})
+	gs.Delete("/users/:id", handler).Status(http.StatusNoContent)
+	gs.Get("/users", handler).
+		Summary("list the users").
+		Description("paginated */ by 20").
+		Response(tsPage[openAPIUser]{})
+	gs.Post("/scores", handler).Request(map[string]int{}).Response([]openAPIUser{})
+	gs.Put("/scores/:board", handler).Request([]int{})
+	gs.Get("/files/*path", handler)
+	gs.Get("/hidden", handler).Hide()
+	gs.Sse("/events", nil, nil, func() {})
+
+	ts := gs.TypeScript()
+
+	if !strings.HasPrefix(ts, tsHeader) {
+		t.Fatal("the client has no runtime header")
+	}
+
+	for prefix, want := range map[string]string{
+		"export function getHealth(": "export function getHealth(init?: RequestInit): Promise<string> {\n" +
+			"\treturn request<string>(\"GET\", `/health`, {}, {}, init);\n}\n",
+
+		"export function getUsersId(": "export function getUsersId(req: { id: string }, init?: RequestInit): Promise<openAPIUser> {\n" +
+			"\treturn request<openAPIUser>(\"GET\", `/users/${encodeURIComponent(String(req.id))}`, req, {  }, init);\n}\n",
+
+		"export function putUsersId(": "export function putUsersId(req: openAPIUpdateUser, init?: RequestInit): Promise<unknown> {\n" +
+			"\treturn request<unknown>(\"PUT\", `/users/${encodeURIComponent(String(req.ID))}`, req, " +
+			"{ query: { page: \"Page\" }, header: { \"X-Token\": \"Token\" }, body: [\"name\"] }, init);\n}\n",
+
+		"export function deleteUsersId(": "export function deleteUsersId(req: { id: string }, init?: RequestInit): Promise<void> {\n" +
+			"\treturn request<void>(\"DELETE\", `/users/${encodeURIComponent(String(req.id))}`, req, {  }, init);\n}\n",
+
+		"export function postScores(": "export function postScores(req: Record<string, number>, init?: RequestInit): Promise<openAPIUser[]> {\n" +
+			"\treturn request<openAPIUser[]>(\"POST\", `/scores`, req, { body: true }, init);\n}\n",
+
+		// the route param is not sent in the body
+		"export function putScoresBoard(": "export function putScoresBoard(req: { board: string; body: number[] }, init?: RequestInit): Promise<unknown> {\n" +
+			"\treturn request<unknown>(\"PUT\", `/scores/${encodeURIComponent(String(req.board))}`, req, { body: \"body\" }, init);\n}\n",
+
+		"export function getFilesPath(": "export function getFilesPath(req: { path: string }, init?: RequestInit): Promise<unknown> {\n" +
+			"\treturn request<unknown>(\"GET\", `/files/${encodeURI(String(req.path))}`, req, {  }, init);\n}\n",
+
+		"export interface openAPIUser ": "export interface openAPIUser {\n\tid: number;\n\tname: string;\n\trole: string;\n\temail: string | null;\n" +
+			"\tnick?: string;\n\tfriend: openAPIUser | null;\n\tcreated: string;\n\tavatar: string;\n}\n",
+
+		"export interface tsPage_": "export interface tsPage_github_com4lxprimeGoSvelt_openAPIUser {\n\titems: openAPIUser[];\n\ttotal: number;\n}\n",
+	} {
+		if got := tsDecl(t, ts, prefix); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+
+	if !strings.Contains(ts, "/**\n * list the users\n * paginated *\\/ by 20\n */\nexport function getUsers(") {
+		t.Error("the doc comment is missing or not escaped")
+	}
+
+	for _, hidden := range []string{"getHidden", "getEvents"} {
+		if strings.Contains(ts, hidden) {
+			t.Errorf("the client has %s", hidden)
+		}
+	}
+}
+
+func TestOperationIDCollisions(t *testing.T) {
+	handler := func(c *Context) error { return nil }
+
+	gs := New()
+
+	gs.Get("/users/:id", handler)
+	gs.Get("/users-id", handler)
+	gs.Get("/users_id", handler)
+	gs.Get("/hidden", handler).Hide()
+
+	ts := gs.TypeScript()
+	spec := fetchOpenAPI(t, gs)
+
+	// the client and the document agree on the suffixes
+	for path, id := range map[string]string{
+		"/users/{id}": "getUsersId",
+		"/users-id":   "getUsersId2",
+		"/users_id":   "getUsersId3",
+	} {
+		if got := spec.Paths[path]["get"].OperationID; got != id {
+			t.Errorf("%s: operationId = %s, want %s", path, got, id)
+		}
+
+		if strings.Count(ts, "export function "+id+"(") != 1 {
+			t.Errorf("%s: the client has no single %s function", path, id)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	cp "github.com/otiai10/copy"
@@ -75,6 +76,24 @@ func cleanDir(dir string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
+// true if target exists and is not older than source
+//
+//	like this:
+//	isUpToDate("node_modules", "package.json")
+func isUpToDate(target, source string) bool {
+	targetInfo, err := os.Stat(target)
+	if err != nil {
+		return false
+	}
+
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+
+	return !targetInfo.ModTime().Before(sourceInfo.ModTime())
+}
+
 // This method takes a file path as input
 // and returns a boolean value indicating whether
 // the path represents a file or not, as well
@@ -123,33 +142,113 @@ func calculateFileHash(path string) (string, error) {
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
-func calculateTreeHash(root string) (string, error) {
-	var fileHashes []string
+// hash a build workspace, its src files with their paths (the
+// pages of a batch build can swap) and its vite config (the build
+// format changes with it, e.g. the relative base of the assets)
+func calculateBuildHash(workspace string) (string, error) {
+	var entries []string
+
+	src := filepath.Join(workspace, "src")
+
+	if err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
 
-	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		hash, err := calculateFileHash(path)
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() {
-			hash, err := calculateFileHash(path)
-			if err != nil {
-				return err
-			}
-			fileHashes = append(fileHashes, hash)
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
 		}
 
+		entries = append(entries, filepath.ToSlash(rel)+":"+hash)
+
 		return nil
 	}); err != nil {
 		return "", err
 	}
 
-	sort.Strings(fileHashes)
+	config, err := calculateFileHash(filepath.Join(workspace, "vite.config.ts"))
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(entries)
 	hasher := sha256.New()
 
-	if _, err := hasher.Write([]byte(strings.Join(fileHashes, ""))); err != nil {
+	if _, err := hasher.Write([]byte(strings.Join(entries, "\n") + "\nvite.config.ts:" + config)); err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
+
+// pick the offered mime type that best match an Accept header,
+// the first offer is returned if there is no Accept header
+//
+//	like this:
+//	negotiateType("text/html,*/*;q=0.8", MAppJSON, MTextHTML) // "text/html"
+func negotiateType(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, offer := range offers {
+		q := acceptQuality(accept, offer)
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+// quality of a mime type in an Accept header
+// (exact match > type/* > */*)
+func acceptQuality(accept, mime string) float64 {
+	mimeType, _, _ := strings.Cut(mime, "/")
+
+	q, specificity := 0.0, -1
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		rng := strings.TrimSpace(params[0])
+
+		s := -1
+		switch {
+		case rng == mime:
+			s = 2
+		case rng == mimeType+"/*":
+			s = 1
+		case rng == "*/*":
+			s = 0
+		}
+
+		if s <= specificity {
+			continue
+		}
+
+		pq := 1.0
+		for _, param := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && k == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					pq = f
+				}
+			}
+		}
+
+		q, specificity = pq, s
+	}
+
+	return q
+}
@@ -0,0 +1,397 @@
+package gosvelt
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a failed validation rule of a field
+type FieldError struct {
+	Field   string `json:"field"`           // field path, e.g. address.city or items[0].name
+	Rule    string `json:"rule"`            // failed rule, e.g. min
+	Param   string `json:"param,omitempty"` // rule parameter, e.g. 3
+	Message string `json:"message"`         // human readable message
+}
+
+// ValidationErrors are all the failed rules of a value
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+
+	return "validation: " + strings.Join(msgs, ", ")
+}
+
+// validate a struct using its `validate` tags, nested structs are
+// validated too and every failed rule is returned as ValidationErrors
+//
+//	like this:
+//	type Signup struct {
+//		Email string   `json:"email" validate:"required,email"`
+//		Name  string   `json:"name" validate:"required,min=2,max=32"`
+//		Role  string   `json:"role" validate:"oneof=user admin"`
+//		Tags  []string `json:"tags" validate:"max=5,dive,required"`
+//	}
+//
+// available rules: required, omitempty, min, max, len, regex,
+// email, oneof and dive (the next rules apply to the elements)
+//
+// NOTE: the rules being comma separated, a regex cannot contain commas
+func Validate(v interface{}) error {
+	vr := &validator{visiting: make(map[visit]bool)}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() || vr.visit(rv) == nil {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if err := vr.validateStruct(rv, ""); err != nil {
+		return err
+	}
+
+	if len(vr.errs) > 0 {
+		return vr.errs
+	}
+
+	return nil
+}
+
+// validate v and convert the failed rules to a 422 http error
+// with the field errors as details
+func validateRequest(v interface{}) error {
+	err := Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		return ErrUnprocessableEntity.WithInternal(err).WithDetails(ve)
+	}
+
+	return ErrInternalServerError.WithInternal(err)
+}
+
+// the state of a validation
+type validator struct {
+	errs     ValidationErrors
+	visiting map[visit]bool // pointers, maps and slices being validated
+}
+
+// a value referenced by a pointer, a map or a slice, a
+// value visited again while it's validated is a cycle
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func (vr *validator) validateStruct(rv reflect.Value, path string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		// the exported fields of an unexported embedded struct are
+		// validated, like encoding/json
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		if path != "" && !field.Anonymous {
+			name = path + "." + name
+
+		} else if field.Anonymous {
+			name = path
+		}
+
+		if err := vr.validateValue(rv.Field(i), name, field.Tag.Get("validate")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the name of a field as seen by the client
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "param", "header", "xml"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func (vr *validator) validateValue(fv reflect.Value, name, tag string) error {
+	var rules, dive []string
+	if tag != "" {
+		rules = strings.Split(tag, ",")
+	}
+
+	for i, rule := range rules {
+		if rule == "dive" {
+			rules, dive = rules[:i], rules[i+1:]
+			break
+		}
+	}
+
+	for _, rule := range rules {
+		if rule == "omitempty" && fv.IsZero() {
+			return nil
+		}
+	}
+
+	for _, rule := range rules {
+		rule, param, _ := strings.Cut(rule, "=")
+
+		msg, err := checkRule(fv, rule, param)
+		if err != nil {
+			return fmt.Errorf("validate: %s: %w", name, err)
+		}
+
+		if msg != "" {
+			vr.errs = append(vr.errs, FieldError{
+				Field:   name,
+				Rule:    rule,
+				Param:   param,
+				Message: msg,
+			})
+
+			return nil // don't pile up errors on a same field
+		}
+	}
+
+	ev := fv
+	for ev.Kind() == reflect.Pointer || ev.Kind() == reflect.Interface {
+		if ev.IsNil() {
+			return nil
+		}
+
+		if ev.Kind() == reflect.Pointer {
+			done := vr.visit(ev)
+			if done == nil {
+				return nil // a cycle, it's validated up the path
+			}
+			defer done()
+		}
+
+		ev = ev.Elem()
+	}
+
+	switch ev.Kind() {
+	case reflect.Struct:
+		return vr.validateStruct(ev, name)
+
+	case reflect.Slice, reflect.Array:
+		if dive == nil {
+			return nil
+		}
+
+		if ev.Kind() == reflect.Slice {
+			done := vr.visit(ev)
+			if done == nil {
+				return nil
+			}
+			defer done()
+		}
+
+		for i := 0; i < ev.Len(); i++ {
+			if err := vr.validateValue(ev.Index(i), fmt.Sprintf("%s[%d]", name, i), strings.Join(dive, ",")); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if dive == nil {
+			return nil
+		}
+
+		done := vr.visit(ev)
+		if done == nil {
+			return nil
+		}
+		defer done()
+
+		iter := ev.MapRange()
+		for iter.Next() {
+			if err := vr.validateValue(iter.Value(), fmt.Sprintf("%s[%v]", name, iter.Key()), strings.Join(dive, ",")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mark a pointer, a map or a slice as being validated, nil is
+// returned if it already is (a cycle), else a func to unmark it
+func (vr *validator) visit(v reflect.Value) (done func()) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+
+	if vr.visiting[key] {
+		return nil
+	}
+
+	vr.visiting[key] = true
+
+	return func() {
+		delete(vr.visiting, key)
+	}
+}
+
+// check a rule, a non empty message is returned if it fails
+// and an error if the rule itself is invalid
+func checkRule(fv reflect.Value, rule, param string) (string, error) {
+	switch rule {
+	case "", "omitempty":
+		return "", nil
+
+	case "required":
+		if fv.IsZero() || ((fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map) && fv.Len() == 0) {
+			return "is required", nil
+		}
+
+		return "", nil
+	}
+
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return "", nil // only required check nil values
+		}
+
+		fv = fv.Elem()
+	}
+
+	switch rule {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s parameter %q", rule, param)
+		}
+
+		size, isLength, ok := measure(fv)
+		if !ok {
+			return "", fmt.Errorf("%s cannot be used on %s", rule, fv.Type())
+		}
+
+		unit := ""
+		if isLength {
+			unit = " in length"
+		}
+
+		switch {
+		case rule == "min" && size < limit:
+			return fmt.Sprintf("must be at least %s%s", param, unit), nil
+		case rule == "max" && size > limit:
+			return fmt.Sprintf("must be at most %s%s", param, unit), nil
+		case rule == "len" && size != limit:
+			return fmt.Sprintf("must be exactly %s in length", param), nil
+		}
+
+	case "regex":
+		if fv.Kind() != reflect.String {
+			return "", fmt.Errorf("regex cannot be used on %s", fv.Type())
+		}
+
+		re, err := compileRegex(param)
+		if err != nil {
+			return "", err
+		}
+
+		if !re.MatchString(fv.String()) {
+			return "has an invalid format", nil
+		}
+
+	case "email":
+		if fv.Kind() != reflect.String {
+			return "", fmt.Errorf("email cannot be used on %s", fv.Type())
+		}
+
+		if addr, err := mail.ParseAddress(fv.String()); err != nil || addr.Address != fv.String() {
+			return "must be a valid email", nil
+		}
+
+	case "oneof":
+		value := fmt.Sprint(fv.Interface())
+
+		for _, option := range strings.Fields(param) {
+			if value == option {
+				return "", nil
+			}
+		}
+
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", ")), nil
+
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule)
+	}
+
+	return "", nil
+}
+
+// the size of a value, for strings, slices and maps it's a length
+func measure(fv reflect.Value) (size float64, isLength bool, ok bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true, true
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(fv.Len()), true, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false, true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false, true
+
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false, true
+	}
+
+	return 0, false, false
+}
+
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q (%s)", pattern, err)
+	}
+
+	regexCache.Store(pattern, re)
+
+	return re, nil
+}
@@ -0,0 +1,215 @@
+package gosvelt
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type validateItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validateNode struct {
+	Name string          `json:"name" validate:"required"`
+	Next *validateNode   `json:"next"`
+	Kids []*validateNode `json:"kids" validate:"dive"`
+}
+
+func TestValidate(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []FieldError // nil if valid
+	}{
+		{
+			name: "valid",
+			value: struct {
+				Email string   `json:"email" validate:"required,email"`
+				Name  string   `json:"name" validate:"min=2,max=4"`
+				Role  string   `json:"role" validate:"oneof=user admin"`
+				Age   int      `json:"age" validate:"min=18"`
+				Code  string   `json:"code" validate:"len=3,regex=^[a-z]+$"`
+				Tags  []string `json:"tags" validate:"max=2,dive,required"`
+			}{"a@b.io", "gö", "admin", 18, "abc", []string{"x"}},
+		},
+		{
+			name: "required",
+			value: struct {
+				Name  string            `json:"name" validate:"required"`
+				Tags  []string          `json:"tags" validate:"required"`
+				Attrs map[string]string `json:"attrs" validate:"required"`
+				Ptr   *string           `json:"ptr" validate:"required"`
+			}{Tags: []string{}},
+			want: []FieldError{
+				{Field: "name", Rule: "required", Message: "is required"},
+				{Field: "tags", Rule: "required", Message: "is required"},
+				{Field: "attrs", Rule: "required", Message: "is required"},
+				{Field: "ptr", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name: "bounds",
+			value: struct {
+				Name  string   `json:"name" validate:"min=3"`
+				Age   uint     `json:"age" validate:"max=99"`
+				Score float64  `json:"score" validate:"min=0.5"`
+				Tags  []string `json:"tags" validate:"len=1"`
+			}{"ab", 100, 0.1, nil},
+			want: []FieldError{
+				{Field: "name", Rule: "min", Param: "3", Message: "must be at least 3 in length"},
+				{Field: "age", Rule: "max", Param: "99", Message: "must be at most 99"},
+				{Field: "score", Rule: "min", Param: "0.5", Message: "must be at least 0.5"},
+				{Field: "tags", Rule: "len", Param: "1", Message: "must be exactly 1 in length"},
+			},
+		},
+		{
+			name: "formats",
+			value: struct {
+				Email string `json:"email" validate:"email"`
+				Slug  string `json:"slug" validate:"regex=^[a-z-]+$"`
+				Role  string `json:"role" validate:"oneof=user admin"`
+			}{"Gopher <a@b.io>", "Not A Slug", "root"},
+			want: []FieldError{
+				{Field: "email", Rule: "email", Message: "must be a valid email"},
+				{Field: "slug", Rule: "regex", Param: "^[a-z-]+$", Message: "has an invalid format"},
+				{Field: "role", Rule: "oneof", Param: "user admin", Message: "must be one of user, admin"},
+			},
+		},
+		{
+			name: "omitempty and nil pointers",
+			value: struct {
+				Email string  `json:"email" validate:"omitempty,email"`
+				Name  *string `json:"name" validate:"min=2"`
+				Nick  *string `json:"nick" validate:"min=2"`
+			}{Nick: ptr("a")},
+			want: []FieldError{
+				{Field: "nick", Rule: "min", Param: "2", Message: "must be at least 2 in length"},
+			},
+		},
+		{
+			name: "nested and dive",
+			value: struct {
+				Item  validateItem            `json:"item"`
+				Items []validateItem          `json:"items" validate:"dive"`
+				ByKey map[string]validateItem `json:"by_key" validate:"dive"`
+				Names []string                `json:"names" validate:"dive,min=2"`
+			}{
+				Items: []validateItem{{"a"}, {}},
+				ByKey: map[string]validateItem{"k": {}},
+				Names: []string{"ab", "c"},
+			},
+			want: []FieldError{
+				{Field: "item.name", Rule: "required", Message: "is required"},
+				{Field: "items[1].name", Rule: "required", Message: "is required"},
+				{Field: "by_key[k].name", Rule: "required", Message: "is required"},
+				{Field: "names[1]", Rule: "min", Param: "2", Message: "must be at least 2 in length"},
+			},
+		},
+		{
+			name: "embedded",
+			value: struct {
+				validateItem
+				Other string `json:"-" validate:"required"`
+			}{},
+			want: []FieldError{
+				{Field: "name", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name: "cycles",
+			value: func() *validateNode {
+				n := &validateNode{}
+				n.Next = n
+				n.Kids = []*validateNode{n, {Name: "kid", Next: n}}
+				return n
+			}(),
+			want: []FieldError{
+				{Field: "name", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:  "not a struct",
+			value: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.value)
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			var ve ValidationErrors
+			if !errors.As(err, &ve) {
+				t.Fatalf("got %v, want ValidationErrors", err)
+			}
+
+			if !reflect.DeepEqual([]FieldError(ve), tt.want) {
+				t.Errorf("got %+v\nwant %+v", ve, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"unknown rule", struct {
+			A string `validate:"uuid"`
+		}{}},
+		{"invalid parameter", struct {
+			A string `validate:"min=two"`
+		}{}},
+		{"invalid regex", struct {
+			A string `validate:"regex=["`
+		}{}},
+		{"wrong type", struct {
+			A bool `validate:"max=1"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.value)
+
+			var ve ValidationErrors
+			if err == nil || errors.As(err, &ve) {
+				t.Fatalf("got %v, want a rule error", err)
+			}
+
+			var he *HTTPError
+			if !errors.As(validateRequest(tt.value), &he) || he.Code != http.StatusInternalServerError {
+				t.Errorf("an invalid rule must be an internal error")
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	err := validateRequest(&validateItem{})
+
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got %v, want a 422 http error", err)
+	}
+
+	if details, ok := he.Details.(ValidationErrors); !ok || len(details) != 1 || details[0].Field != "name" {
+		t.Errorf("got details %+v", he.Details)
+	}
+
+	if err := validateRequest(&validateItem{Name: "a"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}